@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	lox "golox/internal"
+	"golox/internal/bench"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const defaultBenchDir = "test/test_files/benchmark"
+
+// runBench runs `golox bench [flags] [files or directories]`, and returns the
+// exit code, which is 1 on errors or regressions.
+func runBench(args []string) int {
+	flags := pflag.NewFlagSet("bench", pflag.ContinueOnError)
+	runs := flags.Int("runs", 5, "runs of each benchmark")
+	backend := flags.String("backend", lox.BackendTreeWalk.String(), "runs benchmarks with 'treewalk' or 'vm'")
+	save := flags.String("save", "", "saves the results as a baseline JSON file")
+	baselinePath := flags.String("baseline", "", "compares the results with a baseline JSON file")
+	threshold := flags.Float64("threshold", 0.1, "max slowdown from the baseline before failing, e.g. 0.1 for 10%")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: golox bench [flags] [files or directories, default %s]\n", defaultBenchDir)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	} else if *runs < 1 {
+		fmt.Fprintln(os.Stderr, "--runs must be at least 1")
+		return 2
+	}
+
+	config := lox.Config{}
+	if b, err := lox.ParseBackend(*backend); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	} else {
+		config.Backend = b
+	}
+
+	var baseline *bench.Report
+	if *baselinePath != "" {
+		if report, err := bench.LoadReport(*baselinePath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		} else if err := bench.CheckBackend(report, config.Backend.String()); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", *baselinePath, err)
+			return 1
+		} else {
+			baseline = report
+		}
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{defaultBenchDir}
+	}
+	files, err := bench.Files(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	report := &bench.Report{Backend: config.Backend.String(), Results: nil}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	if baseline != nil {
+		fmt.Fprintln(w, "benchmark\truns\tmean\tstddev\tallocs/run\tbytes/run\tbaseline\tchange\t")
+	} else {
+		fmt.Fprintln(w, "benchmark\truns\tmean\tstddev\tallocs/run\tbytes/run\t")
+	}
+	for _, file := range files {
+		result, err := bench.RunFile(config, file, *runs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, err)
+			return 1
+		}
+		report.Results = append(report.Results, result)
+
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%d\t%d\t",
+			result.Name, result.Runs, result.Mean().Round(time.Millisecond), result.Stddev().Round(time.Millisecond),
+			result.AllocsPerOp, result.BytesPerOp,
+		)
+		if baseline == nil {
+			fmt.Fprintln(w)
+		} else if base, ok := baseline.Find(result.Name); ok {
+			fmt.Fprintf(w, "%s\t%+.1f%%\t\n", base.Mean().Round(time.Millisecond), 100*bench.Change(base, result))
+		} else {
+			fmt.Fprintln(w, "-\t-\t")
+		}
+	}
+	w.Flush()
+
+	if *save != "" {
+		if err := bench.SaveReport(*save, report); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	if baseline != nil {
+		if names, err := bench.Regressions(baseline, report, *threshold); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		} else if len(names) > 0 {
+			fmt.Fprintf(os.Stderr, "regressions of more than %.0f%%: %v\n", 100**threshold, names)
+			return 1
+		}
+	}
+	return 0
+}
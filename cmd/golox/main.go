@@ -1,37 +1,58 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	lox "golox/internal"
 	"golox/internal/runner"
-	"log"
+	"os"
 
 	"github.com/spf13/pflag"
 )
 
-func handleErr(err error) {
-	fmt.Println(err)
-}
-
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		os.Exit(runBench(os.Args[2:]))
+	}
+
 	// parse flags:
-	pflag.BoolVar(&lox.ConfigIsDebug, "debug", false, "enables debug logs")
+	config := lox.Config{}
+	pflag.BoolVar(&config.IsDebug, "debug", false, "enables debug logs")
+	pflag.IntVar(&config.MaxCallDepth, "max-call-depth", lox.DefaultMaxCallDepth, "max depth of nested function calls")
+	pflag.StringSliceVar(&config.ModulePaths, "module-path", nil, "directories searched for imported modules, after the directory of the importing file")
+	pflag.IntVar(&config.MaxSteps, "max-steps", 0, "max loop iterations and function calls of a script, 0 means unlimited")
+	timeout := pflag.Duration("timeout", 0, "max running time of a script, 0 means unlimited")
+	backend := pflag.String("backend", lox.BackendTreeWalk.String(), "runs scripts with 'treewalk' or 'vm'")
 	pflag.Parse()
 
+	if b, err := lox.ParseBackend(*backend); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	} else {
+		config.Backend = b
+	}
+
 	// parse args:
 	args := pflag.Args()
 
-	// init logger:
-	log.SetFlags(0)
-
 	// init runner:
-	r := runner.NewRunner(lox.ConfigIsDebug)
+	r := runner.NewRunner(config)
+
+	handleErr := func(err error) {
+		r.RenderError(os.Stderr, err, lox.IsColorSupported(os.Stderr))
+	}
 
 	switch {
 	case len(args) > 1:
 		panic("usage: lox [script_path]")
 	case len(args) == 1:
-		if err := r.RunFile(args[0]); err != nil {
+		ctx := context.Background()
+		if *timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, *timeout)
+			defer cancel()
+		}
+		if err := r.RunFileContext(ctx, args[0]); err != nil {
 			handleErr(err)
 		}
 	default:
@@ -0,0 +1,155 @@
+// Package golox embeds the golox Lox interpreter in Go programs.
+//
+//	engine := golox.NewEngine(golox.Options{})
+//	engine.SetGlobal("limit", 10)
+//	val, err := engine.Eval("limit * 2;")
+package golox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	lox "golox/internal"
+	"golox/internal/interpreter"
+	"golox/internal/runner"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	evalSrcPath = "<eval#%d>" // numbered by run, e.g. "<eval#3>"
+)
+
+type Options struct {
+	IsDebug      bool      // enables debug logs
+	Stdout       io.Writer // for program outputs, defaults to os.Stdout
+	Stderr       io.Writer // for debug logs, defaults to os.Stderr
+	MaxCallDepth int       // max depth of nested Lox calls, defaults to DefaultMaxCallDepth
+	MaxSteps     int       // max loop iterations and calls per run, 0 means unlimited
+	Clock        Clock     // for clock(), nanotime() and now(), defaults to the system clock
+	ModulePaths  []string  // searched for imported modules, after the directory of the importing file
+	AllocationLimits
+}
+
+// Clock is the time source of the time builtins. Tests can replace it to get
+// deterministic times.
+type Clock = lox.Clock
+
+// AllocationLimits caps the allocations of a run, 0 means unlimited. A run
+// exceeding a limit returns an error wrapping ErrAllocationLimit.
+type AllocationLimits = lox.AllocationLimits
+
+// DefaultMaxCallDepth is the max call depth if Options.MaxCallDepth is 0.
+// Deeper calls are "stack overflow" runtime errors.
+const DefaultMaxCallDepth = lox.DefaultMaxCallDepth
+
+func (opts Options) config() lox.Config {
+	return lox.Config{
+		IsDebug:          opts.IsDebug,
+		Stdout:           opts.Stdout,
+		Stderr:           opts.Stderr,
+		MaxCallDepth:     opts.MaxCallDepth,
+		MaxSteps:         opts.MaxSteps,
+		Clock:            opts.Clock,
+		ModulePaths:      opts.ModulePaths,
+		AllocationLimits: opts.AllocationLimits,
+	}
+}
+
+// Engine is an interpreter session. Globals defined by a run are visible to
+// all later runs of the same Engine.
+//
+// An Engine is not safe for concurrent use.
+type Engine struct {
+	runner  *runner.Runner
+	natives map[string]*interpreter.NativeFunction
+	evals   int // run so far, to number their source paths
+}
+
+// Eval runs source in the session. If source ends with an expression
+// statement, its value is returned, e.g. Eval("1 + 2;") returns 3.
+func (e *Engine) Eval(source string) (Value, error) {
+	return e.EvalContext(context.Background(), source)
+}
+
+// EvalContext is Eval, aborted when ctx is done. An aborted run returns an
+// error wrapping ErrCancelled and the error of ctx.
+//
+// Each source has its own path in the errors, e.g. "<eval#3>" for the third
+// one, so that an error raised by a function of an earlier source is rendered
+// with the line of that source.
+func (e *Engine) EvalContext(ctx context.Context, source string) (Value, error) {
+	e.evals++
+	srcPath := fmt.Sprintf(evalSrcPath, e.evals)
+	val, err := e.runner.RunSourceContext(ctx, []rune(source), srcPath)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{raw: val}, nil
+}
+
+// RunFile runs the script at path in the session.
+func (e *Engine) RunFile(path string) (Value, error) {
+	return e.RunFileContext(context.Background(), path)
+}
+
+// RunFileContext is RunFile, aborted when ctx is done.
+func (e *Engine) RunFileContext(ctx context.Context, path string) (Value, error) {
+	srcPath, err := filepath.Abs(path)
+	if err != nil {
+		return Value{}, err
+	}
+
+	source, err := os.ReadFile(srcPath)
+	if err != nil {
+		return Value{}, err
+	}
+
+	val, err := e.runner.RunSourceContext(ctx, bytes.Runes(source), srcPath)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{raw: val}, nil
+}
+
+// RenderError writes err to w. Diagnostics are rendered with the offending
+// source lines, optionally colored with ANSI escape codes.
+func (e *Engine) RenderError(w io.Writer, err error, isColored bool) {
+	e.runner.RenderError(w, err, isColored)
+}
+
+// Global returns the value of the global variable name.
+func (e *Engine) Global(name string) (Value, bool) {
+	val, ok := e.runner.Interpreter().GetGlobal(name)
+	return Value{raw: val}, ok
+}
+
+// SetGlobal defines or overwrites the global variable name. val is converted
+// by ValueOf.
+func (e *Engine) SetGlobal(name string, val any) error {
+	v, err := ValueOf(val)
+	if err != nil {
+		return err
+	}
+
+	e.runner.Interpreter().SetGlobal(name, v.raw)
+	return nil
+}
+
+// Reset drops all globals defined in the session, except functions defined by
+// RegisterFunc.
+func (e *Engine) Reset() {
+	e.runner.Reset()
+	for name, native := range e.natives {
+		e.runner.Interpreter().SetGlobal(name, native)
+	}
+}
+
+func NewEngine(opts Options) *Engine {
+	return &Engine{
+		runner:  runner.NewRunner(opts.config()),
+		natives: map[string]*interpreter.NativeFunction{},
+		evals:   0,
+	}
+}
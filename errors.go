@@ -0,0 +1,117 @@
+package golox
+
+import (
+	lox "golox/internal"
+)
+
+// Diagnostic is a problem found in a Lox program. Errors returned by an Engine
+// for problems in Lox programs are Diagnostics:
+//
+//	var diags golox.Diagnostics
+//	if errors.As(err, &diags) {
+//		for _, diag := range diags {
+//			...
+//		}
+//	}
+type Diagnostic = lox.Diagnostic
+
+// Diagnostics is a list of problems found in a run, in the order found.
+type Diagnostics = lox.Diagnostics
+
+// A run aborted by the host returns a runtime Diagnostic wrapping one of these
+// errors, so that it can be told apart from errors in the Lox program:
+//
+//	if errors.Is(err, golox.ErrCancelled) {
+//		...
+//	}
+var (
+	ErrCancelled       = lox.ErrCancelled       // the context of the run is done
+	ErrBudgetExceeded  = lox.ErrBudgetExceeded  // the run took more than Options.MaxSteps
+	ErrAllocationLimit = lox.ErrAllocationLimit // the run allocated more than Options.AllocationLimits
+)
+
+// StackFrame is a function call in the stack trace of a runtime Diagnostic.
+type StackFrame = lox.StackFrame
+
+type (
+	Severity  = lox.Severity
+	Phase     = lox.Phase
+	ErrorCode = lox.ErrorCode
+	Location  = lox.Location
+)
+
+const (
+	SeverityError   = lox.SeverityError
+	SeverityWarning = lox.SeverityWarning
+)
+
+const (
+	PhaseLexer    = lox.PhaseLexer
+	PhaseParser   = lox.PhaseParser
+	PhaseResolver = lox.PhaseResolver
+	PhaseCompiler = lox.PhaseCompiler
+	PhaseRuntime  = lox.PhaseRuntime
+)
+
+const (
+	// lexer:
+	ErrorCodeUnexpectedCharacter = lox.ErrorCodeUnexpectedCharacter
+	ErrorCodeUnterminatedString  = lox.ErrorCodeUnterminatedString
+	ErrorCodeInvalidNumber       = lox.ErrorCodeInvalidNumber
+
+	// parser:
+	ErrorCodeUnexpectedToken         = lox.ErrorCodeUnexpectedToken
+	ErrorCodeUnexpectedDeclaration   = lox.ErrorCodeUnexpectedDeclaration
+	ErrorCodeUnclosedBlock           = lox.ErrorCodeUnclosedBlock
+	ErrorCodeTooManyParameters       = lox.ErrorCodeTooManyParameters
+	ErrorCodeTooManyArguments        = lox.ErrorCodeTooManyArguments
+	ErrorCodeInvalidAssignmentTarget = lox.ErrorCodeInvalidAssignmentTarget
+
+	// resolver:
+	ErrorCodeVariableAlreadyDefined   = lox.ErrorCodeVariableAlreadyDefined
+	ErrorCodeVariableInOwnInitializer = lox.ErrorCodeVariableInOwnInitializer
+	ErrorCodeTopLevelReturn           = lox.ErrorCodeTopLevelReturn
+	ErrorCodeReturnValueInInitializer = lox.ErrorCodeReturnValueInInitializer
+	ErrorCodeTopLevelThis             = lox.ErrorCodeTopLevelThis
+	ErrorCodeSuperOutsideClass        = lox.ErrorCodeSuperOutsideClass
+	ErrorCodeSuperWithoutSuperclass   = lox.ErrorCodeSuperWithoutSuperclass
+	ErrorCodeClassInheritsFromItself  = lox.ErrorCodeClassInheritsFromItself
+	ErrorCodeBreakOutsideLoop         = lox.ErrorCodeBreakOutsideLoop
+	ErrorCodeContinueOutsideLoop      = lox.ErrorCodeContinueOutsideLoop
+	ErrorCodeExportOutsideTopLevel    = lox.ErrorCodeExportOutsideTopLevel
+
+	// compiler:
+	ErrorCodeTooManyLocals    = lox.ErrorCodeTooManyLocals
+	ErrorCodeTooManyUpvalues  = lox.ErrorCodeTooManyUpvalues
+	ErrorCodeTooManyConstants = lox.ErrorCodeTooManyConstants
+	ErrorCodeJumpTooLarge     = lox.ErrorCodeJumpTooLarge
+	ErrorCodeTooManyElements  = lox.ErrorCodeTooManyElements
+
+	// runtime:
+	ErrorCodeUndefinedVariable    = lox.ErrorCodeUndefinedVariable
+	ErrorCodeInvalidOperand       = lox.ErrorCodeInvalidOperand
+	ErrorCodeInvalidCallee        = lox.ErrorCodeInvalidCallee
+	ErrorCodeArityMismatch        = lox.ErrorCodeArityMismatch
+	ErrorCodeInvalidInstance      = lox.ErrorCodeInvalidInstance
+	ErrorCodeInvalidThis          = lox.ErrorCodeInvalidThis
+	ErrorCodeInvalidSuperclass    = lox.ErrorCodeInvalidSuperclass
+	ErrorCodeUndefinedProperty    = lox.ErrorCodeUndefinedProperty
+	ErrorCodeNativeFunctionFailed = lox.ErrorCodeNativeFunctionFailed
+	ErrorCodeStackOverflow        = lox.ErrorCodeStackOverflow
+	ErrorCodeCancelled            = lox.ErrorCodeCancelled
+	ErrorCodeBudgetExceeded       = lox.ErrorCodeBudgetExceeded
+	ErrorCodeAllocationLimit      = lox.ErrorCodeAllocationLimit
+	ErrorCodeIndexOutOfRange      = lox.ErrorCodeIndexOutOfRange
+	ErrorCodeInvalidIndex         = lox.ErrorCodeInvalidIndex
+	ErrorCodeNotIndexable         = lox.ErrorCodeNotIndexable
+	ErrorCodeInvalidKey           = lox.ErrorCodeInvalidKey
+	ErrorCodeUndefinedKey         = lox.ErrorCodeUndefinedKey
+	ErrorCodeUncaughtException    = lox.ErrorCodeUncaughtException
+	ErrorCodeModuleNotFound       = lox.ErrorCodeModuleNotFound
+	ErrorCodeImportCycle          = lox.ErrorCodeImportCycle
+	ErrorCodeUndefinedExport      = lox.ErrorCodeUndefinedExport
+	ErrorCodeModuleUnreadable     = lox.ErrorCodeModuleUnreadable
+
+	// any phase:
+	ErrorCodeMissingImplementation = lox.ErrorCodeMissingImplementation
+)
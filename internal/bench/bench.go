@@ -0,0 +1,188 @@
+// Package bench runs Lox scripts repeatedly to measure the interpreter, and
+// compares the measures with a saved baseline.
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	golox "golox/internal"
+	"golox/internal/runner"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Result is the measures of a benchmark script over all its runs.
+type Result struct {
+	Name        string  `json:"name"` // file name without the .lox extension
+	Runs        int     `json:"runs"`
+	MeanNs      float64 `json:"mean_ns"`
+	StddevNs    float64 `json:"stddev_ns"`
+	AllocsPerOp uint64  `json:"allocs_per_op"`
+	BytesPerOp  uint64  `json:"bytes_per_op"`
+}
+
+func (r Result) Mean() time.Duration {
+	return time.Duration(r.MeanNs)
+}
+
+func (r Result) Stddev() time.Duration {
+	return time.Duration(r.StddevNs)
+}
+
+// Report is a set of results, saved as a baseline in JSON.
+type Report struct {
+	Backend string   `json:"backend"`
+	Results []Result `json:"results"`
+}
+
+// Find returns the result of the benchmark name.
+func (rp *Report) Find(name string) (Result, bool) {
+	for _, result := range rp.Results {
+		if result.Name == name {
+			return result, true
+		}
+	}
+	return Result{}, false
+}
+
+// timeBoundFiles are the scripts running for a fixed time instead of a fixed
+// amount of work, e.g. zoo_batch.lox loops for 10 seconds, so their mean time
+// cannot show a regression.
+var timeBoundFiles = map[string]bool{
+	"zoo_batch.lox": true,
+}
+
+// Files returns the .lox files of paths, which are files or directories. The
+// time-bound scripts of directories are skipped, but not the ones passed as
+// files.
+func Files(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		if info, err := os.Stat(path); err != nil {
+			return nil, err
+		} else if !info.IsDir() {
+			files = append(files, path)
+		} else if matches, err := filepath.Glob(filepath.Join(path, "*.lox")); err != nil {
+			return nil, err
+		} else {
+			sort.Strings(matches)
+			for _, match := range matches {
+				if !timeBoundFiles[filepath.Base(match)] {
+					files = append(files, match)
+				}
+			}
+		}
+	}
+	return files, nil
+}
+
+// RunFile runs the script at path runs times in new sessions. The outputs of
+// the script are discarded.
+func RunFile(config golox.Config, path string, runs int) (Result, error) {
+	config.Stdout = io.Discard
+	r := runner.NewRunner(config)
+
+	durations := make([]float64, 0, runs)
+	var allocs, bytes uint64
+	var before, after runtime.MemStats
+	for i := 0; i < runs; i++ {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		if err := r.RunFile(path); err != nil {
+			return Result{}, err
+		}
+		durations = append(durations, float64(time.Since(start)))
+		runtime.ReadMemStats(&after)
+		allocs += after.Mallocs - before.Mallocs
+		bytes += after.TotalAlloc - before.TotalAlloc
+	}
+
+	mean, stddev := meanStddev(durations)
+	return Result{
+		Name:        strings.TrimSuffix(filepath.Base(path), ".lox"),
+		Runs:        runs,
+		MeanNs:      mean,
+		StddevNs:    stddev,
+		AllocsPerOp: allocs / uint64(runs),
+		BytesPerOp:  bytes / uint64(runs),
+	}, nil
+}
+
+func meanStddev(xs []float64) (float64, float64) {
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	mean := sum / float64(len(xs))
+
+	if len(xs) < 2 {
+		return mean, 0
+	}
+	var squares float64
+	for _, x := range xs {
+		squares += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(xs)-1))
+}
+
+// Change is the relative change of the mean time of result from baseline, e.g.
+// 0.1 for 10% slower.
+func Change(baseline Result, result Result) float64 {
+	return result.MeanNs/baseline.MeanNs - 1
+}
+
+// CheckBackend returns an error if baseline was not measured on backend, as
+// the times of different backends cannot be compared.
+func CheckBackend(baseline *Report, backend string) error {
+	if baseline.Backend != backend {
+		return fmt.Errorf("the baseline is measured on the %s backend, not %s", baseline.Backend, backend)
+	}
+	return nil
+}
+
+// Regressions returns the names of the benchmarks of report slower than in
+// baseline by more than threshold, e.g. 0.1 for 10%. Both reports must be
+// measured on the same backend.
+func Regressions(baseline *Report, report *Report, threshold float64) ([]string, error) {
+	if err := CheckBackend(baseline, report.Backend); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, result := range report.Results {
+		if base, ok := baseline.Find(result.Name); ok && Change(base, result) > threshold {
+			names = append(names, result.Name)
+		}
+	}
+	return names, nil
+}
+
+// LoadReport reads a report saved by SaveReport.
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %w", path, err)
+	}
+	return report, nil
+}
+
+// SaveReport writes report to path as indented JSON.
+func SaveReport(path string, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,16 @@
+package golox
+
+import "time"
+
+// Clock is the time source of the time builtins, which can be replaced to make
+// tests deterministic.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock of the operating system.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
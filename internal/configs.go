@@ -1,5 +1,104 @@
 package golox
 
-var (
-	ConfigIsDebug bool = false
+import (
+	"fmt"
+	"io"
+	"os"
 )
+
+// Config is passed down to every module of a run, so that each run can be
+// configured separately.
+type Config struct {
+	Backend      Backend
+	IsDebug      bool      // enables debug logs
+	Stdin        io.Reader // for interactive sessions, nil means os.Stdin
+	Stdout       io.Writer // for program outputs, nil means os.Stdout
+	Stderr       io.Writer // for debug logs, nil means os.Stderr
+	MaxCallDepth int       // max depth of nested Lox calls, 0 means DefaultMaxCallDepth
+	MaxSteps     int       // max loop iterations and calls per run, 0 means unlimited
+	Clock        Clock     // for the time builtins, nil means SystemClock
+	ModulePaths  []string  // searched for imported modules, after the directory of the importing file
+	AllocationLimits
+}
+
+// Backend executes the programs of a run.
+type Backend int
+
+const (
+	BackendTreeWalk Backend = iota // walks the AST, slower but easier to debug
+	BackendVM                      // compiles the AST to bytecode for a stack VM
+)
+
+func (b Backend) String() string {
+	switch b {
+	case BackendTreeWalk:
+		return "treewalk"
+	case BackendVM:
+		return "vm"
+	default:
+		return fmt.Sprintf("Backend(%d)", int(b))
+	}
+}
+
+// ParseBackend returns the Backend named s, see Backend.String.
+func ParseBackend(s string) (Backend, error) {
+	for _, b := range []Backend{BackendTreeWalk, BackendVM} {
+		if b.String() == s {
+			return b, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown backend '%s', expected 'treewalk' or 'vm'", s)
+}
+
+// AllocationLimits caps the allocations of a run, 0 means unlimited. All
+// allocations since the start of a run are counted, including the ones that
+// are no longer used, so that the counts do not depend on garbage collection.
+type AllocationLimits struct {
+	MaxStringBytes int // of strings created by concatenation
+	MaxInstances   int
+	MaxFields      int // of all instances
+	MaxScopes      int // block and function scopes, only function calls for BackendVM
+	MaxElements    int // of lists, including the lists created by methods, and entries of maps
+}
+
+// DefaultMaxCallDepth is deep enough for recursive scripts, and shallow enough
+// to stay far below the Go stack limit, as each Lox call takes a few Go frames.
+const DefaultMaxCallDepth = 1024
+
+func (c Config) CallDepthLimit() int {
+	if c.MaxCallDepth <= 0 {
+		return DefaultMaxCallDepth
+	}
+	return c.MaxCallDepth
+}
+
+func (c Config) ClockSource() Clock {
+	if c.Clock == nil {
+		return SystemClock{}
+	}
+	return c.Clock
+}
+
+// os.Stdin, os.Stdout and os.Stderr are resolved lazily, as they can be
+// replaced after creating the config, e.g. in Go examples.
+
+func (c Config) StdinReader() io.Reader {
+	if c.Stdin == nil {
+		return os.Stdin
+	}
+	return c.Stdin
+}
+
+func (c Config) StdoutWriter() io.Writer {
+	if c.Stdout == nil {
+		return os.Stdout
+	}
+	return c.Stdout
+}
+
+func (c Config) StderrWriter() io.Writer {
+	if c.Stderr == nil {
+		return os.Stderr
+	}
+	return c.Stderr
+}
@@ -0,0 +1,68 @@
+package golox
+
+// ErrorCode identifies the kind of a Diagnostic. The first letter is the phase
+// reporting it.
+type ErrorCode string
+
+const (
+	// lexer:
+	ErrorCodeUnexpectedCharacter ErrorCode = "L0001"
+	ErrorCodeUnterminatedString  ErrorCode = "L0002"
+	ErrorCodeInvalidNumber       ErrorCode = "L0003"
+
+	// parser:
+	ErrorCodeUnexpectedToken         ErrorCode = "P0001"
+	ErrorCodeUnexpectedDeclaration   ErrorCode = "P0002"
+	ErrorCodeUnclosedBlock           ErrorCode = "P0003"
+	ErrorCodeTooManyParameters       ErrorCode = "P0004"
+	ErrorCodeTooManyArguments        ErrorCode = "P0005"
+	ErrorCodeInvalidAssignmentTarget ErrorCode = "P0006"
+
+	// resolver:
+	ErrorCodeVariableAlreadyDefined   ErrorCode = "R0001"
+	ErrorCodeVariableInOwnInitializer ErrorCode = "R0002"
+	ErrorCodeTopLevelReturn           ErrorCode = "R0003"
+	ErrorCodeReturnValueInInitializer ErrorCode = "R0004"
+	ErrorCodeTopLevelThis             ErrorCode = "R0005"
+	ErrorCodeSuperOutsideClass        ErrorCode = "R0006"
+	ErrorCodeSuperWithoutSuperclass   ErrorCode = "R0007"
+	ErrorCodeClassInheritsFromItself  ErrorCode = "R0008"
+	ErrorCodeBreakOutsideLoop         ErrorCode = "R0009"
+	ErrorCodeContinueOutsideLoop      ErrorCode = "R0010"
+	ErrorCodeExportOutsideTopLevel    ErrorCode = "R0011"
+
+	// compiler:
+	ErrorCodeTooManyLocals    ErrorCode = "C0001"
+	ErrorCodeTooManyUpvalues  ErrorCode = "C0002"
+	ErrorCodeTooManyConstants ErrorCode = "C0003"
+	ErrorCodeJumpTooLarge     ErrorCode = "C0004"
+	ErrorCodeTooManyElements  ErrorCode = "C0005"
+
+	// runtime:
+	ErrorCodeUndefinedVariable    ErrorCode = "E0001"
+	ErrorCodeInvalidOperand       ErrorCode = "E0002"
+	ErrorCodeInvalidCallee        ErrorCode = "E0003"
+	ErrorCodeArityMismatch        ErrorCode = "E0004"
+	ErrorCodeInvalidInstance      ErrorCode = "E0005"
+	ErrorCodeInvalidThis          ErrorCode = "E0006"
+	ErrorCodeInvalidSuperclass    ErrorCode = "E0007"
+	ErrorCodeUndefinedProperty    ErrorCode = "E0008"
+	ErrorCodeNativeFunctionFailed ErrorCode = "E0009"
+	ErrorCodeStackOverflow        ErrorCode = "E0010"
+	ErrorCodeCancelled            ErrorCode = "E0011"
+	ErrorCodeBudgetExceeded       ErrorCode = "E0012"
+	ErrorCodeAllocationLimit      ErrorCode = "E0013"
+	ErrorCodeIndexOutOfRange      ErrorCode = "E0014"
+	ErrorCodeInvalidIndex         ErrorCode = "E0015"
+	ErrorCodeNotIndexable         ErrorCode = "E0016"
+	ErrorCodeInvalidKey           ErrorCode = "E0017"
+	ErrorCodeUndefinedKey         ErrorCode = "E0018"
+	ErrorCodeUncaughtException    ErrorCode = "E0019"
+	ErrorCodeModuleNotFound       ErrorCode = "E0020"
+	ErrorCodeImportCycle          ErrorCode = "E0021"
+	ErrorCodeUndefinedExport      ErrorCode = "E0022"
+	ErrorCodeModuleUnreadable     ErrorCode = "E0023"
+
+	// any phase:
+	ErrorCodeMissingImplementation ErrorCode = "X0001"
+)
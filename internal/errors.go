@@ -1,9 +1,217 @@
 package golox
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
-func NewErrorf(loc Location, format string, args ...any) error {
-	return fmt.Errorf("%s: %s",
-		loc, fmt.Sprintf(format, args...),
-	)
+// Runtime errors raised for aborted runs wrap one of these errors, so that
+// they can be told apart from errors in the Lox program by errors.Is.
+var (
+	ErrCancelled       = errors.New("execution cancelled")
+	ErrBudgetExceeded  = errors.New("step budget exceeded")
+	ErrAllocationLimit = errors.New("allocation limit exceeded")
+)
+
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Phase is the phase of a run in which a Diagnostic is reported.
+type Phase int
+
+const (
+	PhaseLexer Phase = iota
+	PhaseParser
+	PhaseResolver
+	PhaseCompiler
+	PhaseRuntime
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseLexer:
+		return "lexer"
+	case PhaseParser:
+		return "parser"
+	case PhaseResolver:
+		return "resolver"
+	case PhaseCompiler:
+		return "compiler"
+	case PhaseRuntime:
+		return "runtime"
+	default:
+		return fmt.Sprintf("Phase(%d)", int(p))
+	}
+}
+
+// Label marks a secondary source span related to a Diagnostic.
+type Label struct {
+	Start   Location
+	End     Location // exclusive, zero if unknown
+	Message string
+}
+
+// StackFrame is a function call in a runtime stack trace.
+type StackFrame struct {
+	FunctionName string
+	ClassName    string // empty if the function is not a method
+	CallSite     Location
+}
+
+func (f StackFrame) String() string {
+	name := f.FunctionName
+	if f.ClassName != "" {
+		name = f.ClassName + "." + name
+	}
+	return name + ", called at " + f.CallSite.String()
+}
+
+// Long stack traces, e.g. of stack overflows, are shown with the middle frames
+// elided.
+const (
+	maxStackTraceHead = 10
+	maxStackTraceTail = 3
+)
+
+// stackTraceLines returns a line per frame of trace.
+func stackTraceLines(trace []StackFrame) []string {
+	lines := []string{}
+	for i, frame := range trace {
+		if len(trace) > maxStackTraceHead+maxStackTraceTail+1 {
+			if i == maxStackTraceHead {
+				lines = append(lines, fmt.Sprintf("... %d more frames", len(trace)-maxStackTraceHead-maxStackTraceTail))
+			}
+			if i >= maxStackTraceHead && i < len(trace)-maxStackTraceTail {
+				continue
+			}
+		}
+		lines = append(lines, "in "+frame.String())
+	}
+	return lines
+}
+
+// Diagnostic is a problem found in a Lox program.
+type Diagnostic struct {
+	Severity
+	Phase
+	Code       ErrorCode
+	Start      Location
+	End        Location // exclusive, zero if unknown
+	Message    string
+	Labels     []Label
+	Notes      []string
+	StackTrace []StackFrame // for runtime errors, the most recent call first
+	Err        error        // the underlying error, if any
+}
+
+// Error formats d as "path:line:col: message", followed by one line per label,
+// note and stack frame.
+func (d *Diagnostic) Error() string {
+	var b strings.Builder
+	b.WriteString(d.Start.String())
+	b.WriteString(": ")
+	b.WriteString(d.Message)
+	for _, label := range d.Labels {
+		b.WriteString("\n\t")
+		b.WriteString(label.Start.String())
+		b.WriteString(": ")
+		b.WriteString(label.Message)
+	}
+	for _, note := range d.Notes {
+		b.WriteString("\n\tnote: ")
+		b.WriteString(note)
+	}
+	for _, line := range stackTraceLines(d.StackTrace) {
+		b.WriteString("\n\t")
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+func (d *Diagnostic) Unwrap() error {
+	return d.Err
+}
+
+// WithNote appends a note to d and returns d.
+func (d *Diagnostic) WithNote(format string, args ...any) *Diagnostic {
+	d.Notes = append(d.Notes, fmt.Sprintf(format, args...))
+	return d
+}
+
+// WithLabel appends a label to d and returns d.
+func (d *Diagnostic) WithLabel(start Location, end Location, format string, args ...any) *Diagnostic {
+	d.Labels = append(d.Labels, Label{
+		Start:   start,
+		End:     end,
+		Message: fmt.Sprintf(format, args...),
+	})
+	return d
+}
+
+// Diagnostics is a list of problems found in a run, in the order found.
+type Diagnostics []*Diagnostic
+
+func (ds Diagnostics) Error() string {
+	var b strings.Builder
+	for i, d := range ds {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(d.Error())
+	}
+	return b.String()
+}
+
+// Unwrap allows errors.Is and errors.As to match any of the diagnostics.
+func (ds Diagnostics) Unwrap() []error {
+	errs := make([]error, len(ds))
+	for i, d := range ds {
+		errs[i] = d
+	}
+	return errs
+}
+
+func NewDiagnostic(
+	phase Phase,
+	code ErrorCode,
+	start Location,
+	end Location,
+	format string,
+	args ...any,
+) *Diagnostic {
+	return &Diagnostic{
+		Severity: SeverityError,
+		Phase:    phase,
+		Code:     code,
+		Start:    start,
+		End:      end,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// NewDiagnosticAtToken returns a Diagnostic spanning tkn.
+func NewDiagnosticAtToken(
+	phase Phase,
+	code ErrorCode,
+	tkn Token,
+	format string,
+	args ...any,
+) *Diagnostic {
+	return NewDiagnostic(phase, code, tkn.Location, tkn.End(), format, args...)
 }
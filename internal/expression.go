@@ -23,6 +23,11 @@ func (*ExpressionUnary) implExpression()      {}
 func (*ExpressionBinary) implExpression()     {}
 func (*ExpressionLogical) implExpression()    {}
 func (*ExpressionAssignment) implExpression() {}
+func (*ExpressionFunction) implExpression()   {}
+func (*ExpressionList) implExpression()       {}
+func (*ExpressionMap) implExpression()        {}
+func (*ExpressionIndex) implExpression()      {}
+func (*ExpressionIndexSet) implExpression()   {}
 
 type ExpressionLiteral struct {
 	Location     // not requiring a Token, as the expression can be generated
@@ -61,8 +66,16 @@ func (expr *ExpressionGrouping) String() string {
 	)
 }
 
+// Binding is where a variable is stored, set by the resolver.
+type Binding struct {
+	IsLocal bool // false for global variables, which are looked up by name
+	Depth   int  // number of scopes between the use and the declaration
+	Slot    int  // index of the variable in its scope, in declaration order
+}
+
 type ExpressionVariable struct {
 	Identifier Token
+	Binding
 }
 
 func (expr *ExpressionVariable) GetLocation() Location {
@@ -131,6 +144,7 @@ func (expr *ExpressionSet) String() string {
 
 type ExpressionThis struct {
 	ThisToken Token
+	Binding
 }
 
 func (expr *ExpressionThis) GetLocation() Location {
@@ -144,6 +158,7 @@ func (expr *ExpressionThis) String() string {
 type ExpressionSuper struct {
 	SuperToken Token
 	Method     Token
+	Binding    // of "super", "this" is in the next inner scope
 }
 
 func (expr *ExpressionSuper) GetLocation() Location {
@@ -206,6 +221,7 @@ func (expr *ExpressionLogical) String() string {
 type ExpressionAssignment struct {
 	Identifier Token
 	Value      Expression
+	Binding
 }
 
 func (expr *ExpressionAssignment) GetLocation() Location {
@@ -217,3 +233,106 @@ func (expr *ExpressionAssignment) String() string {
 		expr.Identifier.Lexeme, expr.Value,
 	)
 }
+
+// ExpressionFunction is an anonymous function, e.g. fun (a) { return a; } or
+// (a) => a. Its declaration is named after its location.
+type ExpressionFunction struct {
+	Declaration *StatementFun
+}
+
+func (expr *ExpressionFunction) GetLocation() Location {
+	return expr.Declaration.GetLocation()
+}
+
+func (expr *ExpressionFunction) String() string {
+	var builder strings.Builder
+	for i, param := range expr.Declaration.Parameters {
+		if i != 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(param.Lexeme)
+	}
+	return fmt.Sprintf("(fun %s(%s))",
+		expr.Declaration.Identifier.Lexeme, builder.String(),
+	)
+}
+
+type ExpressionList struct {
+	LeftBracket Token
+	Elements    []Expression
+}
+
+func (expr *ExpressionList) GetLocation() Location {
+	return expr.LeftBracket.Location
+}
+
+func (expr *ExpressionList) String() string {
+	var builder strings.Builder
+	for i, element := range expr.Elements {
+		if i != 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(element.String())
+	}
+	return fmt.Sprintf("(list [%s])",
+		builder.String(),
+	)
+}
+
+type ExpressionMap struct {
+	LeftBrace Token
+	Keys      []Expression
+	Values    []Expression
+}
+
+func (expr *ExpressionMap) GetLocation() Location {
+	return expr.LeftBrace.Location
+}
+
+func (expr *ExpressionMap) String() string {
+	var builder strings.Builder
+	for i, key := range expr.Keys {
+		if i != 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(key.String())
+		builder.WriteString(": ")
+		builder.WriteString(expr.Values[i].String())
+	}
+	return fmt.Sprintf("(map {%s})",
+		builder.String(),
+	)
+}
+
+type ExpressionIndex struct {
+	Object      Expression
+	LeftBracket Token
+	Index       Expression
+}
+
+func (expr *ExpressionIndex) GetLocation() Location {
+	return expr.Object.GetLocation()
+}
+
+func (expr *ExpressionIndex) String() string {
+	return fmt.Sprintf("(getIndex %s[%s])",
+		expr.Object, expr.Index,
+	)
+}
+
+type ExpressionIndexSet struct {
+	Object      Expression
+	LeftBracket Token
+	Index       Expression
+	Value       Expression
+}
+
+func (expr *ExpressionIndexSet) GetLocation() Location {
+	return expr.Object.GetLocation()
+}
+
+func (expr *ExpressionIndexSet) String() string {
+	return fmt.Sprintf("(setIndex %s[%s] %s)",
+		expr.Object, expr.Index, expr.Value,
+	)
+}
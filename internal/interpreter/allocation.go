@@ -0,0 +1,70 @@
+package interpreter
+
+import golox "golox/internal"
+
+// allocations counts the allocations of a run, which are capped by
+// golox.AllocationLimits.
+type allocations struct {
+	stringBytes int
+	instances   int
+	fields      int
+	scopes      int
+	elements    int
+}
+
+// allocateString counts a new string of n bytes created at tkn.
+func (itp *Interpreter) allocateString(n int, tkn golox.Token) error {
+	itp.run.allocs.stringBytes += n
+	if limit := itp.limits.MaxStringBytes; limit > 0 && itp.run.allocs.stringBytes > limit {
+		return itp.newErrorAllocationLimit(tkn.Location, "string bytes", limit)
+	}
+	return nil
+}
+
+// allocateInstance counts a new instance of c.
+func (itp *Interpreter) allocateInstance(c *LoxClass) error {
+	itp.run.allocs.instances++
+	if limit := itp.limits.MaxInstances; limit > 0 && itp.run.allocs.instances > limit {
+		return itp.newErrorAllocationLimit(c.Identifier.Location, "instances", limit)
+	}
+	return nil
+}
+
+// allocateField counts the field identifier of ins, if it is a new field.
+func (itp *Interpreter) allocateField(ins *LoxInstance, identifier golox.Token) error {
+	if _, ok := ins.Fields[identifier.Lexeme]; ok {
+		return nil
+	}
+
+	itp.run.allocs.fields++
+	if limit := itp.limits.MaxFields; limit > 0 && itp.run.allocs.fields > limit {
+		return itp.newErrorAllocationLimit(identifier.Location, "fields", limit)
+	}
+	return nil
+}
+
+// allocateScope counts a new block or function scope.
+func (itp *Interpreter) allocateScope(loc golox.Location) error {
+	itp.run.allocs.scopes++
+	if limit := itp.limits.MaxScopes; limit > 0 && itp.run.allocs.scopes > limit {
+		return itp.newErrorAllocationLimit(loc, "scopes", limit)
+	}
+	return nil
+}
+
+// allocateElements counts n new elements of lists or entries of maps at loc.
+func (itp *Interpreter) allocateElements(n int, loc golox.Location) error {
+	itp.run.allocs.elements += n
+	if limit := itp.limits.MaxElements; limit > 0 && itp.run.allocs.elements > limit {
+		return itp.newErrorAllocationLimit(loc, "elements", limit)
+	}
+	return nil
+}
+
+// elementAllocator returns an Allocator of the elements added at
+// loc by native methods.
+func (itp *Interpreter) elementAllocator(loc golox.Location) Allocator {
+	return func(n int) error {
+		return itp.allocateElements(n, loc)
+	}
+}
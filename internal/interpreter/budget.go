@@ -0,0 +1,19 @@
+package interpreter
+
+import golox "golox/internal"
+
+// step counts a loop iteration or a call at loc, and checks whether the run
+// should be aborted.
+func (itp *Interpreter) step(loc golox.Location) error {
+	itp.run.steps++
+	if itp.maxSteps > 0 && itp.run.steps > itp.maxSteps {
+		return itp.newErrorBudgetExceeded(loc)
+	}
+
+	select {
+	case <-itp.run.ctx.Done():
+		return itp.newErrorCancelled(loc, itp.run.ctx.Err())
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,73 @@
+package builtins
+
+import (
+	golox "golox/internal"
+	"time"
+)
+
+// builtin functions:
+
+// Clock returns the wall clock time in seconds since the Unix epoch, with a
+// microsecond precision.
+type Clock struct {
+	Source golox.Clock
+}
+
+func (c *Clock) String() string {
+	return "<native fn: clock>"
+}
+
+func (c *Clock) Arity() int {
+	return 0
+}
+
+func (c *Clock) Call(args []any) (any, error) {
+	return float64(c.Source.Now().UnixMicro()) / 1e6, nil
+}
+
+// NanoTime returns the monotonic time in nanoseconds since Start, for
+// measuring durations unaffected by changes of the wall clock.
+type NanoTime struct {
+	Source golox.Clock
+	Start  time.Time
+}
+
+func (t *NanoTime) String() string {
+	return "<native fn: nanotime>"
+}
+
+func (t *NanoTime) Arity() int {
+	return 0
+}
+
+func (t *NanoTime) Call(args []any) (any, error) {
+	return float64(t.Source.Now().Sub(t.Start).Nanoseconds()), nil
+}
+
+// Now returns the wall clock time as an RFC 3339 timestamp in UTC, e.g.
+// "2006-01-02T15:04:05.999Z".
+type Now struct {
+	Source golox.Clock
+}
+
+func (n *Now) String() string {
+	return "<native fn: now>"
+}
+
+func (n *Now) Arity() int {
+	return 0
+}
+
+func (n *Now) Call(args []any) (any, error) {
+	return n.Source.Now().UTC().Format(time.RFC3339Nano), nil
+}
+
+// Globals returns the builtin functions by name, with the time builtins reading
+// source.
+func Globals(source golox.Clock) map[string]any {
+	return map[string]any{
+		"clock":    &Clock{Source: source},
+		"nanotime": &NanoTime{Source: source, Start: source.Now()},
+		"now":      &Now{Source: source},
+	}
+}
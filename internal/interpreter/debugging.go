@@ -3,7 +3,9 @@ package interpreter
 import (
 	"fmt"
 	golox "golox/internal"
-	"log"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
 const (
@@ -15,7 +17,7 @@ func (itp *Interpreter) logDefinedVar(
 	val any,
 ) {
 	if itp.isDebug {
-		log.Printf("%s: %s: defined var '%s' = %v at scope index %d, level %d, currently %s",
+		itp.logger.Printf("%s: %s: defined var '%s' = %v at scope index %d, level %d, currently %s",
 			log_prefix, identifier.Location, identifier.Lexeme, val, len(itp.scopes), itp.currScope().level(), itp.currScope().string(),
 		)
 	}
@@ -23,39 +25,52 @@ func (itp *Interpreter) logDefinedVar(
 
 func (itp *Interpreter) logAssignedVar(
 	identifier golox.Token,
+	binding golox.Binding,
 	val any,
-	scope *Scope,
 ) {
 	if itp.isDebug {
-		log.Printf("%s: %s: assigned var '%s' = %v at scope index %d, level %d, currently %s",
-			log_prefix, identifier.Location, identifier.Lexeme, val, len(itp.scopes), scope.level(), scope.string(),
+		itp.logger.Printf("%s: %s: assigned var '%s' = %v at %s",
+			log_prefix, identifier.Location, identifier.Lexeme, val, bindingString(binding),
 		)
 	}
 }
 
 func (itp *Interpreter) logGotVar(
 	identifier golox.Token,
+	binding golox.Binding,
 	val any,
-	scope *Scope,
 ) {
 	if itp.isDebug {
-		log.Printf("%s: %s: got var '%s' = %v at scope index %d, level %d, currently %s",
-			log_prefix, identifier.Location, identifier.Lexeme, val, len(itp.scopes), scope.level(), scope.string(),
+		itp.logger.Printf("%s: %s: got var '%s' = %v at %s",
+			log_prefix, identifier.Location, identifier.Lexeme, val, bindingString(binding),
 		)
 	}
 }
 
+func bindingString(binding golox.Binding) string {
+	if binding.IsLocal {
+		return fmt.Sprintf("depth %d, slot %d", binding.Depth, binding.Slot)
+	} else {
+		return "global scope"
+	}
+}
+
 func (itp *Interpreter) logGlobalScope() {
 	if itp.isDebug {
-		log.Printf("%s: global scope starts, currently has %s",
-			log_prefix, itp.globals.string(),
+		names := make([]string, 0, len(itp.globals))
+		for name := range itp.globals {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		itp.logger.Printf("%s: global scope starts, currently has %v",
+			log_prefix, names,
 		)
 	}
 }
 
 func (itp *Interpreter) logBeginBlockScope() {
 	if itp.isDebug {
-		log.Printf("%s: scope index %d, level %d starts",
+		itp.logger.Printf("%s: scope index %d, level %d starts",
 			log_prefix, len(itp.scopes), itp.currScope().level(),
 		)
 	}
@@ -63,7 +78,7 @@ func (itp *Interpreter) logBeginBlockScope() {
 
 func (itp *Interpreter) logEndBlockScope() {
 	if itp.isDebug {
-		log.Printf("%s: scope index %d, level %d ends",
+		itp.logger.Printf("%s: scope index %d, level %d ends",
 			log_prefix, len(itp.scopes), itp.currScope().level(),
 		)
 	}
@@ -71,7 +86,7 @@ func (itp *Interpreter) logEndBlockScope() {
 
 func (itp *Interpreter) logBeginFunctionScope() {
 	if itp.isDebug {
-		log.Printf("%s: scope index %d starts at level %d",
+		itp.logger.Printf("%s: scope index %d starts at level %d",
 			log_prefix, len(itp.scopes), itp.currScope().level(),
 		)
 	}
@@ -79,7 +94,7 @@ func (itp *Interpreter) logBeginFunctionScope() {
 
 func (itp *Interpreter) logEndFunctionScope() {
 	if itp.isDebug {
-		log.Printf("%s: scope index %d ends at level %d",
+		itp.logger.Printf("%s: scope index %d ends at level %d",
 			log_prefix, len(itp.scopes), itp.currScope().level(),
 		)
 	}
@@ -90,7 +105,7 @@ func (itp *Interpreter) logExecutedStatementExpression(
 	val any,
 ) {
 	if itp.isDebug {
-		log.Printf("%s: %s: executed StatementExpression: evaluated %s = %v",
+		itp.logger.Printf("%s: %s: executed StatementExpression: evaluated %s = %v",
 			log_prefix, stmt.GetLocation(), stmt.Expression, val,
 		)
 	}
@@ -101,7 +116,7 @@ func (itp *Interpreter) logExecutedStatementVar(
 	val any,
 ) {
 	if itp.isDebug {
-		log.Printf("%s: %s: executed StatementVar: defined variable %s = %v",
+		itp.logger.Printf("%s: %s: executed StatementVar: defined variable %s = %v",
 			log_prefix, stmt.GetLocation(), stmt.Identifier.Lexeme, val,
 		)
 	}
@@ -113,7 +128,7 @@ func (itp *Interpreter) logEvaluatedStatementIfCondition(
 	isTrue bool,
 ) {
 	if itp.isDebug {
-		log.Printf("%s: %s: in StatementIf: evaluated if condition %s = %v, which is %t",
+		itp.logger.Printf("%s: %s: in StatementIf: evaluated if condition %s = %v, which is %t",
 			log_prefix, stmt.GetLocation(), stmt.Condition, val, isTrue,
 		)
 	}
@@ -125,7 +140,7 @@ func (itp *Interpreter) logEvaluatedStatementWhileCondition(
 	isTrue bool,
 ) {
 	if itp.isDebug {
-		log.Printf("%s: %s: in StatementWhile: evaluated while condition %s = %v, which is %t",
+		itp.logger.Printf("%s: %s: in StatementWhile: evaluated while condition %s = %v, which is %t",
 			log_prefix, stmt.GetLocation(), stmt.Condition, val, isTrue,
 		)
 	}
@@ -135,7 +150,7 @@ func (itp *Interpreter) logExecutedStatementFun(
 	stmt *golox.StatementFun,
 ) {
 	if itp.isDebug {
-		log.Printf("%s: %s: executed StatementFun: defined function %s",
+		itp.logger.Printf("%s: %s: executed StatementFun: defined function %s",
 			log_prefix, stmt.GetLocation(), stmt.Identifier.Lexeme,
 		)
 	}
@@ -146,7 +161,7 @@ func (itp *Interpreter) logEvaluatedStatementReturnExpression(
 	val any,
 ) {
 	if itp.isDebug {
-		log.Printf("%s: %s: in StatementReturn: evaluated %s = %v",
+		itp.logger.Printf("%s: %s: in StatementReturn: evaluated %s = %v",
 			log_prefix, stmt.GetLocation(), stmt.Expression, val,
 		)
 	}
@@ -157,7 +172,7 @@ func (itp *Interpreter) logExecutedStatementClass(
 	class *LoxClass,
 ) {
 	if itp.isDebug {
-		log.Printf("%s: %s: executed StatementClass: defined class %s with methods %v",
+		itp.logger.Printf("%s: %s: executed StatementClass: defined class %s with methods %v",
 			log_prefix, stmt.GetLocation(), stmt.Identifier.Lexeme, class.Methods,
 		)
 	}
@@ -168,7 +183,7 @@ func (itp *Interpreter) logEvaluatedStatementPrintExpression(
 	val any,
 ) {
 	if itp.isDebug {
-		log.Printf("%s: %s: in StatementPrint: evaluated %s = %v",
+		itp.logger.Printf("%s: %s: in StatementPrint: evaluated %s = %v",
 			log_prefix, stmt.GetLocation(), stmt.Expression, val,
 		)
 	}
@@ -177,8 +192,10 @@ func (itp *Interpreter) logEvaluatedStatementPrintExpression(
 func (itp *Interpreter) newErrorUndefinedVariable(
 	identifier golox.Token,
 ) error {
-	return fmt.Errorf("%s: undefined variable '%s'",
-		identifier.Location, identifier.Lexeme,
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeUndefinedVariable,
+		identifier,
+		"undefined variable '%s'", identifier.Lexeme,
 	)
 }
 
@@ -186,8 +203,10 @@ func (itp *Interpreter) newErrorOperandMustBe(
 	message string, // e.g. "a number"
 	opTkn golox.Token,
 ) error {
-	return fmt.Errorf("%s: operand must be %s",
-		opTkn.Location, message,
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidOperand,
+		opTkn,
+		"operand must be %s", message,
 	)
 }
 
@@ -195,16 +214,20 @@ func (itp *Interpreter) newErrorOperandsMustBe(
 	message string, // e.g. "both numbers"
 	opTkn golox.Token,
 ) error {
-	return fmt.Errorf("%s: operands must be %s",
-		opTkn.Location, message,
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidOperand,
+		opTkn,
+		"operands must be %s", message,
 	)
 }
 
 func (itp *Interpreter) newErrorInvalidFunctionCallee(
 	callee golox.Expression,
 ) error {
-	return fmt.Errorf("%s: invalid function callee %s",
-		callee.GetLocation(), callee,
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidCallee,
+		callee.GetLocation(), golox.Location{},
+		"invalid function callee %s", callee,
 	)
 }
 
@@ -213,16 +236,96 @@ func (itp *Interpreter) newErrorFunctionArityMismatch(
 	want int,
 	got int,
 ) error {
-	return fmt.Errorf("%s: function call %s expected %d arguments, got %d",
-		expr.GetLocation(), expr, want, got,
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeArityMismatch,
+		expr.GetLocation(), golox.Location{},
+		"function call %s expected %d arguments, got %d", expr, want, got,
+	)
+}
+
+func (itp *Interpreter) newErrorVariadicFunctionArityMismatch(
+	expr golox.Expression,
+	atLeast int,
+	got int,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeArityMismatch,
+		expr.GetLocation(), golox.Location{},
+		"function call %s expected at least %d arguments, got %d", expr, atLeast, got,
 	)
 }
 
+func (itp *Interpreter) newErrorNativeFunctionFailed(
+	expr golox.Expression,
+	fn *NativeFunction,
+	err error,
+) error {
+	diag := golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeNativeFunctionFailed,
+		expr.GetLocation(), golox.Location{},
+		"%s: %s", fn.name, err,
+	)
+	diag.Err = err
+	return diag
+}
+
+func (itp *Interpreter) newErrorStackOverflow(
+	expr golox.Expression,
+	depth int,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeStackOverflow,
+		expr.GetLocation(), golox.Location{},
+		"stack overflow at depth %d", depth,
+	).WithNote("the max call depth is %d", itp.maxCallDepth)
+}
+
+func (itp *Interpreter) newErrorCancelled(
+	loc golox.Location,
+	err error,
+) error {
+	diag := golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeCancelled,
+		loc, golox.Location{},
+		"%s: %s", golox.ErrCancelled, err,
+	)
+	diag.Err = fmt.Errorf("%w: %w", golox.ErrCancelled, err)
+	return diag
+}
+
+func (itp *Interpreter) newErrorBudgetExceeded(
+	loc golox.Location,
+) error {
+	diag := golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeBudgetExceeded,
+		loc, golox.Location{},
+		"%s", golox.ErrBudgetExceeded,
+	).WithNote("the max steps are %d", itp.maxSteps)
+	diag.Err = golox.ErrBudgetExceeded
+	return diag
+}
+
+func (itp *Interpreter) newErrorAllocationLimit(
+	loc golox.Location,
+	what string,
+	limit int,
+) error {
+	diag := golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeAllocationLimit,
+		loc, golox.Location{},
+		"%s: more than %d %s", golox.ErrAllocationLimit, limit, what,
+	)
+	diag.Err = golox.ErrAllocationLimit
+	return diag
+}
+
 func (itp *Interpreter) newErrorInvalidObjectInstance(
 	expr golox.Expression,
 ) error {
-	return fmt.Errorf("%s: invalid object instance %s",
-		expr.GetLocation(), expr,
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidInstance,
+		expr.GetLocation(), golox.Location{},
+		"invalid object instance %s", expr,
 	)
 }
 
@@ -230,16 +333,20 @@ func (itp *Interpreter) newErrorInvalidThisValue(
 	expr golox.Expression,
 	val any,
 ) error {
-	return fmt.Errorf("%s: invalid value (%v) for 'this'",
-		expr.GetLocation(), val,
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidThis,
+		expr.GetLocation(), golox.Location{},
+		"invalid value (%v) for 'this'", val,
 	)
 }
 
 func (itp *Interpreter) newErrorInvalidClass(
 	expr golox.Expression,
 ) error {
-	return fmt.Errorf("%s: invalid class %s",
-		expr.GetLocation(), expr,
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidSuperclass,
+		expr.GetLocation(), golox.Location{},
+		"invalid class %s", expr,
 	)
 }
 
@@ -247,23 +354,154 @@ func (itp *Interpreter) newErrorInvalidSuperclassValue(
 	expr golox.Expression,
 	val any,
 ) error {
-	return fmt.Errorf("%s: invalid superclass %v",
-		expr.GetLocation(), val,
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidSuperclass,
+		expr.GetLocation(), golox.Location{},
+		"invalid superclass %v", val,
 	)
 }
 
-func (c *LoxClass) newErrorUndefinedProperty(
+func newErrorUndefinedProperty(
 	identifier golox.Token,
 ) error {
-	return fmt.Errorf("%s: undefined property '%s'",
-		identifier.Location, identifier.Lexeme,
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeUndefinedProperty,
+		identifier,
+		"undefined property '%s'", identifier.Lexeme,
+	)
+}
+
+func newErrorUndefinedExport(
+	identifier golox.Token,
+	module *LoxModule,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeUndefinedExport,
+		identifier,
+		"undefined export '%s' of module %s", identifier.Lexeme, filepath.Base(module.Path),
+	)
+}
+
+// NewErrorModuleNotFound returns the error of an import of a path not found in
+// any of the searched directories.
+func NewErrorModuleNotFound(
+	path golox.Token,
+	searched []string,
+) error {
+	if len(searched) == 0 {
+		return golox.NewDiagnosticAtToken(
+			golox.PhaseRuntime, golox.ErrorCodeModuleNotFound,
+			path,
+			"module %s not found", path.Lexeme,
+		)
+	}
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeModuleNotFound,
+		path,
+		"module %s not found in %s", path.Lexeme, strings.Join(searched, ", "),
+	)
+}
+
+// NewErrorModuleUnreadable returns the error of an import of a module found at
+// a path which cannot be read, wrapping err.
+func NewErrorModuleUnreadable(
+	path golox.Token,
+	err error,
+) error {
+	diag := golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeModuleUnreadable,
+		path,
+		"module %s cannot be read: %s", path.Lexeme, err,
+	)
+	diag.Err = err
+	return diag
+}
+
+// NewErrorImportCycle returns the error of an import of a module being
+// executed, with cycle the paths of the modules importing each other.
+func NewErrorImportCycle(
+	path golox.Token,
+	cycle []string,
+) error {
+	names := make([]string, len(cycle))
+	for i, p := range cycle {
+		names[i] = filepath.Base(p)
+	}
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeImportCycle,
+		path,
+		"import cycle: %s", strings.Join(names, " -> "),
+	)
+}
+
+func newErrorListIndexOutOfRange(
+	index float64,
+	length int,
+) error {
+	return fmt.Errorf("index %s out of range for list of length %d", Stringify(index), length)
+}
+
+func (itp *Interpreter) newErrorIndexOutOfRange(
+	leftBracket golox.Token,
+	index float64,
+	length int,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeIndexOutOfRange,
+		leftBracket,
+		"%s", newErrorListIndexOutOfRange(index, length),
+	)
+}
+
+func (itp *Interpreter) newErrorInvalidIndex(
+	leftBracket golox.Token,
+	val any,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidIndex,
+		leftBracket,
+		"list index must be an integer, got %s", Stringify(val),
+	)
+}
+
+func (itp *Interpreter) newErrorInvalidKey(
+	key golox.Expression,
+	err error,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidKey,
+		key.GetLocation(), golox.Location{},
+		"%s", err,
+	)
+}
+
+func (itp *Interpreter) newErrorUndefinedKey(
+	leftBracket golox.Token,
+	key any,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeUndefinedKey,
+		leftBracket,
+		"undefined key %s", Stringify(key),
+	)
+}
+
+func (itp *Interpreter) newErrorNotIndexable(
+	expr golox.Expression,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeNotIndexable,
+		expr.GetLocation(), golox.Location{},
+		"invalid indexed object %s, expected a list or a map", expr,
 	)
 }
 
 func (itp *Interpreter) newErrorMissingImplementation(
 	node any,
 ) error {
-	return fmt.Errorf("missing implementation for type %T",
-		node,
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeMissingImplementation,
+		golox.Location{}, golox.Location{},
+		"missing implementation for type %T", node,
 	)
 }
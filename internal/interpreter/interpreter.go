@@ -1,23 +1,48 @@
 package interpreter
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	golox "golox/internal"
 	"golox/internal/interpreter/builtins"
+	"io"
+	"log"
+	"math"
 	"strconv"
+	"strings"
 )
 
 type Interpreter struct {
 	// configs:
-	isDebug bool
-
-	// inputs:
-	// stmts []golox.Statement
-	resolvedLocalVars map[golox.Expression]int
+	isDebug      bool
+	stdout       io.Writer // for program outputs
+	logger       *log.Logger
+	maxCallDepth int
+	maxSteps     int
+	limits       golox.AllocationLimits
+	importer     Importer // nil if imports are not supported
 
 	// states:
-	globals *Scope
-	scopes  []*Scope
+	globals map[string]any
+	scopes  []*Scope  // the innermost scope of each call, nil at the top level
+	run     *runState // shared with the interpreters of the modules it calls
+}
+
+// runState is the state of the current run. The top level of an imported
+// module runs in the interpreter of the module with the run state of the
+// importing run, and its functions with the run state of the interpreter
+// calling them, so that their calls, steps and allocations count in that run
+// and are cancelled with it.
+type runState struct {
+	frames []golox.StackFrame // for stack traces
+	ctx    context.Context
+	steps  int
+	allocs allocations
+}
+
+func (run *runState) Context() context.Context {
+	return run.ctx
 }
 
 func (itp *Interpreter) currScope() *Scope {
@@ -25,7 +50,7 @@ func (itp *Interpreter) currScope() *Scope {
 }
 
 func (itp *Interpreter) nthEnclosingScope(n int) *Scope {
-	// assume n is from itp.resolvedLocalVars
+	// assume n is from a binding set by the resolver
 	scope := itp.currScope()
 	for i := 0; i < n; i++ {
 		scope = scope.Enclosing
@@ -35,8 +60,8 @@ func (itp *Interpreter) nthEnclosingScope(n int) *Scope {
 
 func (itp *Interpreter) beginBlockScope() {
 	itp.scopes[len(itp.scopes)-1] = &Scope{
-		NameToValue: map[string]any{},
-		Enclosing:   itp.currScope(),
+		Values:    nil,
+		Enclosing: itp.currScope(),
 	}
 	itp.logBeginBlockScope()
 }
@@ -46,10 +71,12 @@ func (itp *Interpreter) endBlockScope() {
 	itp.scopes[len(itp.scopes)-1] = itp.currScope().Enclosing
 }
 
-func (itp *Interpreter) beginFunctionScope(closure *Scope) {
+// beginFunctionScope starts the scope of a function call, with the arguments
+// in the first slots.
+func (itp *Interpreter) beginFunctionScope(closure *Scope, args []any) {
 	itp.scopes = append(itp.scopes, &Scope{
-		NameToValue: map[string]any{},
-		Enclosing:   closure,
+		Values:    args,
+		Enclosing: closure,
 	})
 	itp.logBeginFunctionScope()
 }
@@ -59,35 +86,36 @@ func (itp *Interpreter) endFunctionScope() {
 	itp.scopes = itp.scopes[:len(itp.scopes)-1]
 }
 
+// defineVar defines a variable in the next slot of the current scope, which
+// is the slot assigned by the resolver, as variables are defined in the order
+// of their declarations.
 func (itp *Interpreter) defineVar(identifier golox.Token, val any) {
-	itp.currScope().NameToValue[identifier.Lexeme] = val
+	if scope := itp.currScope(); scope == nil {
+		itp.globals[identifier.Lexeme] = val
+	} else {
+		scope.Values = append(scope.Values, val)
+	}
 	itp.logDefinedVar(identifier, val)
 }
 
 func (itp *Interpreter) assignVar(
 	identifier golox.Token,
+	binding golox.Binding,
 	val any,
-	expr golox.Expression,
 ) (
 	any,
 	error,
 ) {
-	if dist, ok := itp.resolvedLocalVars[expr]; ok {
-		scope := itp.nthEnclosingScope(dist)
-
-		if _, ok := scope.NameToValue[identifier.Lexeme]; !ok {
-			return nil, itp.newErrorUndefinedVariable(identifier)
-		} else {
-			scope.NameToValue[identifier.Lexeme] = val
-			itp.logAssignedVar(identifier, val, scope)
-			return val, nil
-		}
+	if binding.IsLocal {
+		itp.nthEnclosingScope(binding.Depth).Values[binding.Slot] = val
+		itp.logAssignedVar(identifier, binding, val)
+		return val, nil
 	} else {
-		if _, ok := itp.globals.NameToValue[identifier.Lexeme]; !ok {
+		if _, ok := itp.globals[identifier.Lexeme]; !ok {
 			return nil, itp.newErrorUndefinedVariable(identifier)
 		} else {
-			itp.globals.NameToValue[identifier.Lexeme] = val
-			itp.logAssignedVar(identifier, val, itp.globals)
+			itp.globals[identifier.Lexeme] = val
+			itp.logAssignedVar(identifier, binding, val)
 			return val, nil
 		}
 	}
@@ -95,25 +123,20 @@ func (itp *Interpreter) assignVar(
 
 func (itp *Interpreter) getVar(
 	identifier golox.Token,
-	expr golox.Expression,
+	binding golox.Binding,
 ) (
 	any,
 	error,
 ) {
-	if dist, ok := itp.resolvedLocalVars[expr]; ok {
-		scope := itp.nthEnclosingScope(dist)
-
-		if val, ok := scope.NameToValue[identifier.Lexeme]; !ok {
-			return nil, itp.newErrorUndefinedVariable(identifier)
-		} else {
-			itp.logGotVar(identifier, val, scope)
-			return val, nil
-		}
+	if binding.IsLocal {
+		val := itp.nthEnclosingScope(binding.Depth).Values[binding.Slot]
+		itp.logGotVar(identifier, binding, val)
+		return val, nil
 	} else {
-		if val, ok := itp.globals.NameToValue[identifier.Lexeme]; !ok {
+		if val, ok := itp.globals[identifier.Lexeme]; !ok {
 			return nil, itp.newErrorUndefinedVariable(identifier)
 		} else {
-			itp.logGotVar(identifier, val, itp.globals)
+			itp.logGotVar(identifier, binding, val)
 			return val, nil
 		}
 	}
@@ -143,30 +166,52 @@ func isValueTruthy(val any) bool {
 	}
 }
 
-func (itp *Interpreter) execute(stmt golox.Statement) error {
+// completionType is how the execution of a statement ends.
+type completionType int
+
+const (
+	completionNormal completionType = iota
+	completionReturn
+	completionBreak
+	completionContinue
+)
+
+// completion is returned by execute, so that control flow statements unwind
+// the enclosing statements, instead of panicking. Errors are returned
+// separately.
+type completion struct {
+	Type  completionType
+	Value any // the returned value of a return statement
+}
+
+var (
+	normalCompletion = completion{Type: completionNormal, Value: nil}
+)
+
+func (itp *Interpreter) execute(stmt golox.Statement) (completion, error) {
 	switch stmt := stmt.(type) {
 	case nil:
-		return nil
+		return normalCompletion, nil
 
 	case *golox.StatementBlock:
-		itp.beginBlockScope()
-		for _, stmt := range stmt.Statements {
-			if err := itp.execute(stmt); err != nil {
-				return err
-			}
+		if err := itp.allocateScope(stmt.Location); err != nil {
+			return normalCompletion, err
 		}
-		itp.endBlockScope()
+		itp.beginBlockScope()
+		c, err := itp.executeStatements(stmt.Statements)
+		itp.endBlockScope() // also on errors and returns, so that no scope is leaked
+		return c, err
 
 	case *golox.StatementExpression:
 		if val, err := itp.evaluate(stmt.Expression); err != nil {
-			return err
+			return normalCompletion, err
 		} else {
 			itp.logExecutedStatementExpression(stmt, val)
 		}
 
 	case *golox.StatementVar:
 		if val, err := itp.evaluate(stmt.Expression); err != nil {
-			return err
+			return normalCompletion, err
 		} else {
 			itp.defineVar(stmt.Identifier, val)
 			itp.logExecutedStatementVar(stmt, val)
@@ -174,42 +219,99 @@ func (itp *Interpreter) execute(stmt golox.Statement) error {
 
 	case *golox.StatementIf:
 		if val, err := itp.evaluate(stmt.Condition); err != nil {
-			return err
+			return normalCompletion, err
 		} else {
 			isTrue := isValueTruthy(val)
 			itp.logEvaluatedStatementIfCondition(stmt, val, isTrue)
 			if isTrue {
-				if err := itp.execute(stmt.Then); err != nil {
-					return err
-				}
+				return itp.execute(stmt.Then)
 			} else {
-				if err := itp.execute(stmt.Else); err != nil {
-					return err
-				}
+				return itp.execute(stmt.Else)
 			}
 		}
 
 	case *golox.StatementWhile:
 		for {
-			if val, err := itp.evaluate(stmt.Condition); err != nil {
-				return err
+			if err := itp.step(stmt.WhileToken.Location); err != nil {
+				return normalCompletion, err
+			} else if val, err := itp.evaluate(stmt.Condition); err != nil {
+				return normalCompletion, err
 			} else {
 				isTrue := isValueTruthy(val)
 				itp.logEvaluatedStatementWhileCondition(stmt, val, isTrue)
-				if isTrue {
-					if err := itp.execute(stmt.Body); err != nil {
-						return err
-					}
-				} else {
+				if !isTrue {
 					break
+				} else if c, err := itp.execute(stmt.Body); err != nil {
+					return normalCompletion, err
+				} else if c.Type == completionReturn {
+					return c, nil
+				} else if c.Type == completionBreak {
+					break
+				} else if _, err := itp.evaluate(stmt.Increment); err != nil {
+					return normalCompletion, err
 				}
+				// continue with the next iteration for both completionNormal
+				// and completionContinue
 			}
 		}
 
+	case *golox.StatementThrow:
+		if val, err := itp.evaluate(stmt.Expression); err != nil {
+			return normalCompletion, err
+		} else {
+			return normalCompletion, NewThrowError(stmt.ThrowToken, val)
+		}
+
+	case *golox.StatementTry:
+		c, err := itp.execute(stmt.Body)
+		if err != nil && !IsCatchable(err) {
+			return normalCompletion, err // aborted runs do not run finally clauses
+		} else if err != nil && stmt.Catch != nil {
+			itp.attachStackTrace(err)
+			c, err = itp.executeCatch(stmt, CaughtValue(err))
+			if err != nil && !IsCatchable(err) {
+				return normalCompletion, err
+			}
+		}
+		if stmt.Finally != nil {
+			// the completion of the finally clause overrides the one of the try
+			if fc, ferr := itp.execute(stmt.Finally); ferr != nil || fc.Type != completionNormal {
+				return fc, ferr
+			}
+		}
+		return c, err
+
+	case *golox.StatementImport:
+		module, err := ImportModule(itp.run, itp.importer, stmt)
+		if err != nil {
+			return normalCompletion, err
+		} else if stmt.Names == nil {
+			itp.defineVar(stmt.Alias, module)
+			return normalCompletion, nil
+		}
+		for _, name := range stmt.Names {
+			if val, err := module.Get(name); err != nil {
+				return normalCompletion, err
+			} else {
+				itp.defineVar(name, val)
+			}
+		}
+		return normalCompletion, nil
+
+	case *golox.StatementExport:
+		return itp.execute(stmt.Declaration)
+
+	case *golox.StatementBreak:
+		return completion{Type: completionBreak, Value: nil}, nil
+
+	case *golox.StatementContinue:
+		return completion{Type: completionContinue, Value: nil}, nil
+
 	case *golox.StatementFun:
 		itp.defineVar(stmt.Identifier, &LoxFunction{
 			Declaration:   stmt,
 			IsInitializer: false,
+			Class:         nil,
 			Closure:       itp.scopes[len(itp.scopes)-1],
 			Interpreter:   itp,
 		})
@@ -217,30 +319,29 @@ func (itp *Interpreter) execute(stmt golox.Statement) error {
 
 	case *golox.StatementReturn:
 		if val, err := itp.evaluate(stmt.Expression); err != nil {
-			return err
+			return normalCompletion, err
 		} else {
 			itp.logEvaluatedStatementReturnExpression(stmt, val)
-			panic(ReturnValue{Value: val}) // caught in utils:functionScope
+			return completion{Type: completionReturn, Value: val}, nil
 		}
 
 	case *golox.StatementClass:
 		result := &LoxClass{}
 		result.Identifier = stmt.Identifier
 		result.Methods = map[string]*LoxFunction{}
+		result.Interpreter = itp
 
 		closure := itp.currScope()
 
 		if stmt.Superclass != nil {
 			if val, err := itp.evaluate(stmt.Superclass); err != nil {
-				return err
+				return normalCompletion, err
 			} else if sc, ok := val.(*LoxClass); !ok {
-				return itp.newErrorInvalidClass(stmt.Superclass)
+				return normalCompletion, itp.newErrorInvalidClass(stmt.Superclass)
 			} else {
 				result.Superclass = sc
 				closure = &Scope{
-					NameToValue: map[string]any{
-						"super": sc,
-					},
+					Values:    []any{sc}, // "super"
 					Enclosing: closure,
 				}
 			}
@@ -250,6 +351,7 @@ func (itp *Interpreter) execute(stmt golox.Statement) error {
 			result.Methods[method.Identifier.Lexeme] = &LoxFunction{
 				Declaration:   method,
 				IsInitializer: method.Identifier.Lexeme == "init",
+				Class:         result,
 				Closure:       closure,
 				Interpreter:   itp,
 			}
@@ -260,29 +362,179 @@ func (itp *Interpreter) execute(stmt golox.Statement) error {
 
 	case *golox.StatementPrint:
 		if val, err := itp.evaluate(stmt.Expression); err != nil {
-			return err
+			return normalCompletion, err
 		} else {
 			itp.logEvaluatedStatementPrintExpression(stmt, val)
-
-			switch val := val.(type) {
-			case nil:
-				fmt.Println("<nil>")
-			case string:
-				fmt.Println("\"" + string(val) + "\"")
-			case float64:
-				fmt.Println(strconv.FormatFloat(val, 'f', -1, 64))
-			default:
-				fmt.Println(val)
-			}
+			fmt.Fprintln(itp.stdout, Stringify(val))
 		}
 
 	default:
-		return itp.newErrorMissingImplementation(stmt)
+		return normalCompletion, itp.newErrorMissingImplementation(stmt)
+	}
+
+	return normalCompletion, nil
+}
+
+// executeCatch executes the catch clause of stmt, with its variable bound to
+// the caught value.
+func (itp *Interpreter) executeCatch(stmt *golox.StatementTry, val any) (completion, error) {
+	if err := itp.allocateScope(stmt.Catch.Location); err != nil {
+		return normalCompletion, err
+	}
+	itp.beginBlockScope()
+	itp.defineVar(stmt.CatchIdentifier, val)
+	c, err := itp.execute(stmt.Catch)
+	itp.endBlockScope() // also on errors, so that no scope is leaked
+	return c, err
+}
+
+// executeStatements executes stmts until one of them does not complete
+// normally.
+func (itp *Interpreter) executeStatements(stmts []golox.Statement) (completion, error) {
+	for _, stmt := range stmts {
+		if c, err := itp.execute(stmt); err != nil {
+			return normalCompletion, err
+		} else if c.Type != completionNormal {
+			return c, nil
+		}
 	}
+	return normalCompletion, nil
+}
 
+func (itp *Interpreter) checkArity(callee LoxCallable, expr *golox.ExpressionCall) error {
+	if fn, ok := callee.(*NativeFunction); ok && fn.IsVariadic() {
+		if len(expr.Arguments) < fn.Arity() {
+			return itp.newErrorVariadicFunctionArityMismatch(expr, fn.Arity(), len(expr.Arguments))
+		}
+	} else if len(expr.Arguments) != callee.Arity() {
+		return itp.newErrorFunctionArityMismatch(expr, callee.Arity(), len(expr.Arguments))
+	}
 	return nil
 }
 
+// call calls callee in a new stack frame. The call depth is limited, so that
+// unbounded recursion is a runtime error instead of a Go stack overflow.
+func (itp *Interpreter) call(
+	callee LoxCallable,
+	args []any,
+	expr *golox.ExpressionCall,
+) (
+	any,
+	error,
+) {
+	if err := itp.step(expr.GetLocation()); err != nil {
+		itp.attachStackTrace(err)
+		return nil, err
+	} else if len(itp.run.frames) >= itp.maxCallDepth {
+		err := itp.newErrorStackOverflow(expr, len(itp.run.frames))
+		itp.attachStackTrace(err)
+		return nil, err
+	}
+
+	if owner := ownerOf(callee); owner != nil && owner.run != itp.run {
+		// callee is a function of an imported module
+		run := owner.run
+		owner.run = itp.run
+		defer func() { owner.run = run }()
+	}
+	itp.beginFrame(callee, expr)
+	val, err := callee.Call(args)
+	if err != nil {
+		// the allocations of native methods are already runtime errors
+		if fn, ok := callee.(*NativeFunction); ok && !errors.Is(err, golox.ErrAllocationLimit) {
+			err = itp.newErrorNativeFunctionFailed(expr, fn, err)
+		}
+		itp.attachStackTrace(err)
+	}
+	itp.endFrame()
+	return val, err
+}
+
+// ownerOf returns the interpreter executing callee, or nil for a native
+// function.
+func ownerOf(callee LoxCallable) *Interpreter {
+	switch callee := callee.(type) {
+	case *LoxFunction:
+		return callee.Interpreter
+	case *LoxClass:
+		return callee.Interpreter
+	default:
+		return nil
+	}
+}
+
+func (itp *Interpreter) evaluateArguments(exprs []golox.Expression) ([]any, error) {
+	args := make([]any, 0, len(exprs))
+	for _, arg := range exprs {
+		if val, err := itp.evaluate(arg); err != nil {
+			return nil, err
+		} else {
+			args = append(args, val)
+		}
+	}
+	return args, nil
+}
+
+// listIndex returns the position in list of the index val, or an error if val
+// is not an integer in the range of list.
+func (itp *Interpreter) listIndex(list *LoxList, val any, leftBracket golox.Token) (int, error) {
+	if index, ok := val.(float64); !ok || index != math.Trunc(index) {
+		return 0, itp.newErrorInvalidIndex(leftBracket, val)
+	} else if i, ok := list.Index(index); !ok {
+		return 0, itp.newErrorIndexOutOfRange(leftBracket, index, len(list.Elements))
+	} else {
+		return i, nil
+	}
+}
+
+// getIndex returns the element of the list or the map obj at index.
+func (itp *Interpreter) getIndex(expr *golox.ExpressionIndex, obj any, index any) (any, error) {
+	switch obj := obj.(type) {
+	case *LoxList:
+		if i, err := itp.listIndex(obj, index, expr.LeftBracket); err != nil {
+			return nil, err
+		} else {
+			return obj.Elements[i], nil
+		}
+	case *LoxMap:
+		if err := CheckMapKey(index); err != nil {
+			return nil, itp.newErrorInvalidKey(expr.Index, err)
+		} else if val, ok := obj.Get(index); !ok {
+			return nil, itp.newErrorUndefinedKey(expr.LeftBracket, index)
+		} else {
+			return val, nil
+		}
+	default:
+		return nil, itp.newErrorNotIndexable(expr.Object)
+	}
+}
+
+// setIndex sets the element of the list or the map obj at index to val.
+func (itp *Interpreter) setIndex(expr *golox.ExpressionIndexSet, obj any, index any, val any) error {
+	switch obj := obj.(type) {
+	case *LoxList:
+		if i, err := itp.listIndex(obj, index, expr.LeftBracket); err != nil {
+			return err
+		} else {
+			obj.Elements[i] = val
+			return nil
+		}
+	case *LoxMap:
+		if err := CheckMapKey(index); err != nil {
+			return itp.newErrorInvalidKey(expr.Index, err)
+		}
+		if _, ok := obj.Get(index); !ok {
+			if err := itp.allocateElements(1, expr.LeftBracket.Location); err != nil {
+				return err
+			}
+		}
+		obj.Set(index, val)
+		return nil
+	default:
+		return itp.newErrorNotIndexable(expr.Object)
+	}
+}
+
 func (itp *Interpreter) evaluate(expr golox.Expression) (any, error) {
 	switch expr := expr.(type) {
 	case nil:
@@ -295,30 +547,32 @@ func (itp *Interpreter) evaluate(expr golox.Expression) (any, error) {
 		return itp.evaluate(expr.Expression)
 
 	case *golox.ExpressionVariable:
-		return itp.getVar(expr.Identifier, expr)
+		return itp.getVar(expr.Identifier, expr.Binding)
 
 	case *golox.ExpressionCall:
 		if val, err := itp.evaluate(expr.Callee); err != nil {
 			return nil, err
 		} else if callee, ok := val.(LoxCallable); !ok {
 			return nil, itp.newErrorInvalidFunctionCallee(expr.Callee)
-		} else if len(expr.Arguments) != callee.Arity() {
-			return nil, itp.newErrorFunctionArityMismatch(expr, callee.Arity(), len(expr.Arguments))
+		} else if err := itp.checkArity(callee, expr); err != nil {
+			return nil, err
+		} else if args, err := itp.evaluateArguments(expr.Arguments); err != nil {
+			return nil, err
 		} else {
-			args := []any{}
-			for _, arg := range expr.Arguments {
-				if val, err := itp.evaluate(arg); err != nil {
-					return nil, err
-				} else {
-					args = append(args, val)
-				}
-			}
-			return callee.Call(args)
+			return itp.call(callee, args, expr)
 		}
 
 	case *golox.ExpressionGet:
 		if val, err := itp.evaluate(expr.Object); err != nil {
 			return nil, err
+		} else if list, ok := val.(*LoxList); ok {
+			return list.Method(expr.Identifier, itp.elementAllocator(expr.Identifier.Location))
+		} else if m, ok := val.(*LoxMap); ok {
+			return m.Method(expr.Identifier, itp.elementAllocator(expr.Identifier.Location))
+		} else if e, ok := val.(*LoxError); ok {
+			return e.Get(expr.Identifier)
+		} else if m, ok := val.(*LoxModule); ok {
+			return m.Get(expr.Identifier)
 		} else if obj, ok := val.(*LoxInstance); !ok {
 			return nil, itp.newErrorInvalidObjectInstance(expr.Object)
 		} else {
@@ -332,22 +586,24 @@ func (itp *Interpreter) evaluate(expr golox.Expression) (any, error) {
 			return nil, itp.newErrorInvalidObjectInstance(expr.Object)
 		} else if val, err := itp.evaluate(expr.Value); err != nil {
 			return nil, err
+		} else if err := itp.allocateField(obj, expr.Identifier); err != nil {
+			return nil, err
 		} else {
 			obj.Set(expr.Identifier, val)
 			return val, nil
 		}
 
 	case *golox.ExpressionThis:
-		return itp.getVar(expr.ThisToken, expr)
+		return itp.getVar(expr.ThisToken, expr.Binding)
 
 	case *golox.ExpressionSuper:
-		if dist, ok := itp.resolvedLocalVars[expr]; !ok {
+		if !expr.IsLocal {
 			return nil, itp.newErrorUndefinedVariable(expr.SuperToken)
-		} else if superclass, ok := itp.nthEnclosingScope(dist).NameToValue["super"].(*LoxClass); !ok {
+		} else if superclass, ok := itp.nthEnclosingScope(expr.Depth).Values[expr.Slot].(*LoxClass); !ok {
 			return nil, itp.newErrorInvalidSuperclassValue(expr, superclass)
 		} else if method, err := superclass.FindMethod(expr.Method); err != nil {
 			return nil, err
-		} else if thisVal, ok := itp.nthEnclosingScope(dist - 1).NameToValue["this"].(*LoxInstance); !ok {
+		} else if thisVal, ok := itp.nthEnclosingScope(expr.Depth - 1).Values[0].(*LoxInstance); !ok {
 			return nil, itp.newErrorInvalidThisValue(expr, thisVal)
 		} else {
 			return method.WithThisBoundTo(thisVal), nil
@@ -416,6 +672,9 @@ func (itp *Interpreter) evaluate(expr golox.Expression) (any, error) {
 					return lhs + rhs, nil
 				}
 				if lhs, rhs, ok := assertTwo[string, string](lhs, rhs); ok {
+					if err := itp.allocateString(len(lhs)+len(rhs), expr.Operator); err != nil {
+						return nil, err
+					}
 					return lhs + rhs, nil
 				}
 				return nil, itp.newErrorOperandsMustBe("both numbers or both strings", expr.Operator)
@@ -455,42 +714,235 @@ func (itp *Interpreter) evaluate(expr golox.Expression) (any, error) {
 		if val, err := itp.evaluate(expr.Value); err != nil {
 			return nil, err
 		} else {
-			return itp.assignVar(expr.Identifier, val, expr)
+			return itp.assignVar(expr.Identifier, expr.Binding, val)
+		}
+
+	case *golox.ExpressionFunction:
+		return &LoxFunction{
+			Declaration:   expr.Declaration,
+			IsInitializer: false,
+			Class:         nil,
+			Closure:       itp.scopes[len(itp.scopes)-1],
+			Interpreter:   itp,
+		}, nil
+
+	case *golox.ExpressionList:
+		if elements, err := itp.evaluateArguments(expr.Elements); err != nil {
+			return nil, err
+		} else if err := itp.allocateElements(len(elements), expr.LeftBracket.Location); err != nil {
+			return nil, err
+		} else {
+			return &LoxList{Elements: elements}, nil
+		}
+
+	case *golox.ExpressionMap:
+		// evaluating all the entries before checking the keys
+		keys := make([]any, len(expr.Keys))
+		values := make([]any, len(expr.Values))
+		for i, keyExpr := range expr.Keys {
+			if key, err := itp.evaluate(keyExpr); err != nil {
+				return nil, err
+			} else if val, err := itp.evaluate(expr.Values[i]); err != nil {
+				return nil, err
+			} else {
+				keys[i], values[i] = key, val
+			}
+		}
+
+		if err := itp.allocateElements(len(keys), expr.LeftBrace.Location); err != nil {
+			return nil, err
+		}
+		m := NewLoxMap()
+		for i, key := range keys {
+			if err := CheckMapKey(key); err != nil {
+				return nil, itp.newErrorInvalidKey(expr.Keys[i], err)
+			}
+			m.Set(key, values[i])
+		}
+		return m, nil
+
+	case *golox.ExpressionIndex:
+		if objVal, err := itp.evaluate(expr.Object); err != nil {
+			return nil, err
+		} else if indexVal, err := itp.evaluate(expr.Index); err != nil {
+			return nil, err
+		} else {
+			return itp.getIndex(expr, objVal, indexVal)
+		}
+
+	case *golox.ExpressionIndexSet:
+		if objVal, err := itp.evaluate(expr.Object); err != nil {
+			return nil, err
+		} else if indexVal, err := itp.evaluate(expr.Index); err != nil {
+			return nil, err
+		} else if val, err := itp.evaluate(expr.Value); err != nil {
+			return nil, err
+		} else if err := itp.setIndex(expr, objVal, indexVal, val); err != nil {
+			return nil, err
+		} else {
+			return val, nil
 		}
 	}
 
 	return nil, itp.newErrorMissingImplementation(expr)
 }
 
+// InterpretStatements executes stmts in the global scope. If the last statement
+// is an expression statement, its value is returned.
+//
+// The run is aborted with a runtime error wrapping golox.ErrCancelled when ctx
+// is done, or golox.ErrBudgetExceeded when it takes more than the max steps.
 func (itp *Interpreter) InterpretStatements(
+	ctx context.Context,
 	stmts []golox.Statement,
-	resolvedLocalVars map[golox.Expression]int,
-) error {
-	itp.resolvedLocalVars = resolvedLocalVars
+) (
+	any,
+	error,
+) {
+	itp.run.ctx = ctx
+	itp.run.steps = 0
+	itp.run.allocs = allocations{}
+	// a run starts at the top level, even if a previous run of the session
+	// was aborted in a block or a call, e.g. by a panic of a native function
+	itp.scopes = append(itp.scopes[:0], nil)
+	itp.run.frames = itp.run.frames[:0]
+	itp.logGlobalScope()
+
+	for i, stmt := range stmts {
+		if stmt, ok := stmt.(*golox.StatementExpression); ok && i == len(stmts)-1 {
+			if val, err := itp.evaluate(stmt.Expression); err != nil {
+				return nil, err
+			} else {
+				itp.logExecutedStatementExpression(stmt, val)
+				return val, nil
+			}
+		}
+
+		if _, err := itp.execute(stmt); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// ImportStatements executes stmts, the top level of an imported module, in the
+// global scope and in run, the run importing the module.
+func (itp *Interpreter) ImportStatements(run Run, stmts []golox.Statement) error {
+	itp.run = run.(*runState)
+	itp.scopes = append(itp.scopes[:0], nil)
 	itp.logGlobalScope()
 
 	for _, stmt := range stmts {
-		if err := itp.execute(stmt); err != nil {
+		if _, err := itp.execute(stmt); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func NewInterpreter(
-	isDebug bool,
-) *Interpreter {
-	globals := &Scope{
-		NameToValue: map[string]any{
-			"clock": &builtins.Clock{},
-		},
-		Enclosing: &Scope{},
+func (itp *Interpreter) GetGlobal(name string) (any, bool) {
+	val, ok := itp.globals[name]
+	return val, ok
+}
+
+// SetGlobal defines or overwrites a global variable.
+func (itp *Interpreter) SetGlobal(name string, val any) {
+	itp.globals[name] = val
+}
+
+// Globals returns the global variables of the session, by name.
+func (itp *Interpreter) Globals() map[string]any {
+	return itp.globals
+}
+
+// SetDebug enables or disables the debug logs.
+func (itp *Interpreter) SetDebug(isDebug bool) {
+	itp.isDebug = isDebug
+}
+
+// SetImporter sets the importer of the modules of import statements.
+func (itp *Interpreter) SetImporter(importer Importer) {
+	itp.importer = importer
+}
+
+// Stringify formats a Lox value the same way as a print statement.
+func Stringify(val any) string {
+	return stringify(val, nil)
+}
+
+// stringify formats val, with the lists and maps being formatted in seen
+// printed as "[...]" and "{...}", so that they can contain themselves.
+func stringify(val any, seen map[any]bool) string {
+	switch val := val.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return "\"" + val + "\""
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case *LoxList:
+		if seen[val] {
+			return "[...]"
+		} else if seen == nil {
+			seen = map[any]bool{}
+		}
+		seen[val] = true
+		defer delete(seen, val)
+
+		var builder strings.Builder
+		builder.WriteByte('[')
+		for i, element := range val.Elements {
+			if i != 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteString(stringify(element, seen))
+		}
+		builder.WriteByte(']')
+		return builder.String()
+	case *LoxMap:
+		if seen[val] {
+			return "{...}"
+		} else if seen == nil {
+			seen = map[any]bool{}
+		}
+		seen[val] = true
+		defer delete(seen, val)
+
+		var builder strings.Builder
+		builder.WriteByte('{')
+		for i, key := range val.keys {
+			if i != 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteString(stringify(key, seen))
+			builder.WriteString(": ")
+			builder.WriteString(stringify(val.entries[key], seen))
+		}
+		builder.WriteByte('}')
+		return builder.String()
+	default:
+		return fmt.Sprint(val)
 	}
+}
 
+func NewInterpreter(
+	config golox.Config,
+) *Interpreter {
 	return &Interpreter{
-		isDebug:           isDebug,
-		resolvedLocalVars: nil,
-		globals:           globals,
-		scopes:            []*Scope{globals},
+		isDebug:      config.IsDebug,
+		stdout:       config.StdoutWriter(),
+		logger:       log.New(config.StderrWriter(), "", 0),
+		maxCallDepth: config.CallDepthLimit(),
+		maxSteps:     config.MaxSteps,
+		limits:       config.AllocationLimits,
+		importer:     nil,
+		globals:      builtins.Globals(config.ClockSource()),
+		scopes:       []*Scope{nil},
+		run: &runState{
+			frames: nil,
+			ctx:    context.Background(),
+			steps:  0,
+			allocs: allocations{},
+		},
 	}
 }
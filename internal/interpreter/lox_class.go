@@ -3,9 +3,10 @@ package interpreter
 import golox "golox/internal"
 
 type LoxClass struct {
-	Identifier golox.Token
-	Superclass *LoxClass
-	Methods    map[string]*LoxFunction
+	Identifier  golox.Token
+	Superclass  *LoxClass
+	Methods     map[string]*LoxFunction
+	Interpreter *Interpreter
 }
 
 // implements Callable
@@ -24,13 +25,18 @@ func (c *LoxClass) Arity() int {
 
 // implements Callable
 func (c *LoxClass) Call(args []any) (any, error) {
+	if err := c.Interpreter.allocateInstance(c); err != nil {
+		return nil, err
+	}
 	ins := &LoxInstance{
 		Class:  c,
 		Fields: map[string]any{},
 	}
 
 	if initMethod, ok := c.FindInit(); ok {
-		initMethod.WithThisBoundTo(ins).Call(args)
+		if _, err := initMethod.WithThisBoundTo(ins).Call(args); err != nil {
+			return nil, err
+		}
 	}
 	return ins, nil
 }
@@ -41,7 +47,7 @@ func (c *LoxClass) FindMethod(identifier golox.Token) (*LoxFunction, error) {
 	} else if c.Superclass != nil {
 		return c.Superclass.FindMethod(identifier)
 	} else {
-		return nil, c.newErrorUndefinedProperty(identifier)
+		return nil, newErrorUndefinedProperty(identifier)
 	}
 }
 
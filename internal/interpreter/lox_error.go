@@ -0,0 +1,85 @@
+package interpreter
+
+import (
+	"errors"
+	golox "golox/internal"
+)
+
+// LoxError is a runtime error caught by a catch clause, e.g. of an invalid
+// operand. Throwing it again raises the same error.
+type LoxError struct {
+	Diagnostic *golox.Diagnostic
+}
+
+func (e *LoxError) String() string {
+	return "<error: " + e.Diagnostic.Message + ">"
+}
+
+// Get returns the property of e named identifier.
+func (e *LoxError) Get(identifier golox.Token) (any, error) {
+	switch identifier.Lexeme {
+	case "message":
+		return e.Diagnostic.Message, nil
+	case "code":
+		return string(e.Diagnostic.Code), nil
+	case "location":
+		return e.Diagnostic.Start.String(), nil
+	case "line":
+		return float64(e.Diagnostic.Start.Line), nil
+	case "trace":
+		trace := make([]any, len(e.Diagnostic.StackTrace))
+		for i, frame := range e.Diagnostic.StackTrace {
+			trace[i] = frame.String()
+		}
+		return &LoxList{Elements: trace}, nil
+	default:
+		return nil, newErrorUndefinedProperty(identifier)
+	}
+}
+
+// ThrownValue is the underlying error of an exception raised by a throw
+// statement, carrying the thrown value until it is caught.
+type ThrownValue struct {
+	Value any
+}
+
+func (t *ThrownValue) Error() string {
+	return "thrown " + Stringify(t.Value)
+}
+
+// NewThrowError returns the error raised by throwing val, which is the caught
+// error itself if val is a LoxError.
+func NewThrowError(throwToken golox.Token, val any) error {
+	if e, ok := val.(*LoxError); ok {
+		return e.Diagnostic
+	}
+	diag := golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeUncaughtException,
+		throwToken,
+		"uncaught exception: %s", Stringify(val),
+	)
+	diag.Err = &ThrownValue{Value: val}
+	return diag
+}
+
+// IsCatchable reports whether err can be caught by a try statement. Runs
+// aborted by their context or their limits can not be recovered from.
+func IsCatchable(err error) bool {
+	var diag *golox.Diagnostic
+	return errors.As(err, &diag) && diag.Phase == golox.PhaseRuntime &&
+		!errors.Is(err, golox.ErrCancelled) &&
+		!errors.Is(err, golox.ErrBudgetExceeded) &&
+		!errors.Is(err, golox.ErrAllocationLimit)
+}
+
+// CaughtValue returns the value bound by a catch clause catching err: the
+// thrown value, or a LoxError for other runtime errors.
+func CaughtValue(err error) any {
+	var thrown *ThrownValue
+	if errors.As(err, &thrown) {
+		return thrown.Value
+	}
+	var diag *golox.Diagnostic
+	errors.As(err, &diag) // a catchable error is a Diagnostic
+	return &LoxError{Diagnostic: diag}
+}
@@ -0,0 +1,149 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	golox "golox/internal"
+	"math"
+)
+
+// LoxList is a list created by a list literal, e.g. [1, 2, 3].
+type LoxList struct {
+	Elements []any
+}
+
+func (l *LoxList) String() string {
+	return Stringify(l)
+}
+
+// Index returns the position of the element at index, which counts from the
+// end of the list if negative, e.g. -1 for the last element.
+func (l *LoxList) Index(index float64) (int, bool) {
+	if index < 0 {
+		index += float64(len(l.Elements))
+	}
+	if index < 0 || index >= float64(len(l.Elements)) {
+		return 0, false
+	}
+	return int(index), true
+}
+
+// Allocator counts n new elements of lists, and returns an error if the run
+// exceeds its allocation limits.
+type Allocator func(n int) error
+
+// Method returns the method of l named identifier, bound to l. The elements
+// added by the method are counted by allocate.
+func (l *LoxList) Method(identifier golox.Token, allocate Allocator) (*NativeFunction, error) {
+	switch identifier.Lexeme {
+	case "push":
+		return NewNativeFunction("push", 1, false, func(args []any) (any, error) {
+			if err := allocate(1); err != nil {
+				return nil, err
+			}
+			l.Elements = append(l.Elements, args[0])
+			return nil, nil
+		}), nil
+	case "pop":
+		return NewNativeFunction("pop", 0, false, func(args []any) (any, error) {
+			if len(l.Elements) == 0 {
+				return nil, errors.New("pop from an empty list")
+			}
+			last := l.Elements[len(l.Elements)-1]
+			l.Elements = l.Elements[:len(l.Elements)-1]
+			return last, nil
+		}), nil
+	case "len":
+		return NewNativeFunction("len", 0, false, func(args []any) (any, error) {
+			return float64(len(l.Elements)), nil
+		}), nil
+	case "slice":
+		return NewNativeFunction("slice", 1, true, func(args []any) (any, error) {
+			return l.slice(args, allocate)
+		}), nil
+	case "insert":
+		return NewNativeFunction("insert", 2, false, func(args []any) (any, error) {
+			index, err := integerArgument(args[0])
+			if err != nil {
+				return nil, err
+			}
+			// inserting at the length appends
+			i := index
+			if i < 0 {
+				i += float64(len(l.Elements))
+			}
+			if i < 0 || i > float64(len(l.Elements)) {
+				return nil, newErrorListIndexOutOfRange(index, len(l.Elements))
+			} else if err := allocate(1); err != nil {
+				return nil, err
+			}
+			l.Elements = append(l.Elements, nil)
+			copy(l.Elements[int(i)+1:], l.Elements[int(i):])
+			l.Elements[int(i)] = args[1]
+			return nil, nil
+		}), nil
+	case "remove":
+		return NewNativeFunction("remove", 1, false, func(args []any) (any, error) {
+			index, err := integerArgument(args[0])
+			if err != nil {
+				return nil, err
+			}
+			i, ok := l.Index(index)
+			if !ok {
+				return nil, newErrorListIndexOutOfRange(index, len(l.Elements))
+			}
+			removed := l.Elements[i]
+			l.Elements = append(l.Elements[:i], l.Elements[i+1:]...)
+			return removed, nil
+		}), nil
+	case "contains":
+		return NewNativeFunction("contains", 1, false, func(args []any) (any, error) {
+			for _, element := range l.Elements {
+				if element == args[0] {
+					return true, nil
+				}
+			}
+			return false, nil
+		}), nil
+	default:
+		return nil, newErrorUndefinedProperty(identifier)
+	}
+}
+
+// slice returns a new list of the elements from args[0] to args[1], or to the
+// end. Negative bounds count from the end, and bounds out of the list are
+// clamped.
+func (l *LoxList) slice(args []any, allocate Allocator) (any, error) {
+	if len(args) > 2 {
+		return nil, fmt.Errorf("expected at most 2 arguments, got %d", len(args))
+	}
+
+	bounds := []float64{0, float64(len(l.Elements))}
+	for i, arg := range args {
+		bound, err := integerArgument(arg)
+		if err != nil {
+			return nil, err
+		}
+		if bound < 0 {
+			bound += float64(len(l.Elements))
+		}
+		bounds[i] = math.Max(0, math.Min(bound, float64(len(l.Elements))))
+	}
+	start, end := int(bounds[0]), int(math.Max(bounds[0], bounds[1]))
+	if err := allocate(end - start); err != nil {
+		return nil, err
+	}
+
+	elements := make([]any, end-start)
+	copy(elements, l.Elements[start:end])
+	return &LoxList{Elements: elements}, nil
+}
+
+// integerArgument returns val if it is an integer number, e.g. an index.
+func integerArgument(val any) (float64, error) {
+	if n, ok := val.(float64); !ok || n != math.Trunc(n) {
+		return 0, fmt.Errorf("index must be an integer, got %s", Stringify(val))
+	} else {
+		return n, nil
+	}
+}
@@ -0,0 +1,125 @@
+package interpreter
+
+import (
+	"fmt"
+	golox "golox/internal"
+	"math"
+)
+
+// LoxMap is a map created by a map literal, e.g. {"a": 1}. Its keys are
+// strings, numbers, booleans or nil, and are kept in insertion order.
+type LoxMap struct {
+	keys    []any
+	entries map[any]any
+}
+
+func NewLoxMap() *LoxMap {
+	return &LoxMap{
+		keys:    []any{},
+		entries: map[any]any{},
+	}
+}
+
+func (m *LoxMap) String() string {
+	return Stringify(m)
+}
+
+// Len returns the number of entries of m.
+func (m *LoxMap) Len() int {
+	return len(m.keys)
+}
+
+// Keys returns the keys of m in insertion order.
+func (m *LoxMap) Keys() []any {
+	keys := make([]any, len(m.keys))
+	copy(keys, m.keys)
+	return keys
+}
+
+// Get returns the value of key, and whether m has key.
+func (m *LoxMap) Get(key any) (any, bool) {
+	val, ok := m.entries[key]
+	return val, ok
+}
+
+// Set sets the value of key, keeping the position of key if m already has it.
+func (m *LoxMap) Set(key any, val any) {
+	if key == -0.0 {
+		key = 0.0 // -0 and 0 are the same key
+	}
+	if _, ok := m.entries[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.entries[key] = val
+}
+
+// Delete removes key from m, and returns whether m had key.
+func (m *LoxMap) Delete(key any) bool {
+	if _, ok := m.entries[key]; !ok {
+		return false
+	}
+	delete(m.entries, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Method returns the method of m named identifier, bound to m. The elements
+// of the lists created by the method are counted by allocate.
+func (m *LoxMap) Method(identifier golox.Token, allocate Allocator) (*NativeFunction, error) {
+	switch identifier.Lexeme {
+	case "keys":
+		return NewNativeFunction("keys", 0, false, func(args []any) (any, error) {
+			if err := allocate(len(m.keys)); err != nil {
+				return nil, err
+			}
+			return &LoxList{Elements: m.Keys()}, nil
+		}), nil
+	case "values":
+		return NewNativeFunction("values", 0, false, func(args []any) (any, error) {
+			if err := allocate(len(m.keys)); err != nil {
+				return nil, err
+			}
+			values := make([]any, len(m.keys))
+			for i, key := range m.keys {
+				values[i] = m.entries[key]
+			}
+			return &LoxList{Elements: values}, nil
+		}), nil
+	case "has":
+		return NewNativeFunction("has", 1, false, func(args []any) (any, error) {
+			_, ok := m.entries[args[0]]
+			return ok, nil
+		}), nil
+	case "delete":
+		return NewNativeFunction("delete", 1, false, func(args []any) (any, error) {
+			return m.Delete(args[0]), nil
+		}), nil
+	case "len":
+		return NewNativeFunction("len", 0, false, func(args []any) (any, error) {
+			return float64(len(m.keys)), nil
+		}), nil
+	default:
+		return nil, newErrorUndefinedProperty(identifier)
+	}
+}
+
+// CheckMapKey returns an error if val can not be a key of a map. Keys are
+// compared by value, so only values with a well-defined equality are allowed.
+func CheckMapKey(val any) error {
+	switch val := val.(type) {
+	case nil, bool, string:
+		return nil
+	case float64:
+		if math.IsNaN(val) {
+			return fmt.Errorf("map key must not be NaN")
+		}
+		return nil
+	default:
+		return fmt.Errorf("map key must be a string, a number, a boolean or nil, got %s", Stringify(val))
+	}
+}
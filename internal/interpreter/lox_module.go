@@ -0,0 +1,50 @@
+package interpreter
+
+import (
+	"context"
+	golox "golox/internal"
+	"path/filepath"
+)
+
+// Importer loads the modules of import statements, see runner.Runner.
+type Importer interface {
+	// Import returns the module imported by stmt, executing its file on its
+	// first import in the session. The file is executed in run, the run of
+	// the import statement, so that it counts in the budgets of that run.
+	Import(run Run, stmt *golox.StatementImport) (*LoxModule, error)
+}
+
+// Run is the state of the run executing an import statement, which is only
+// known to the backend running it.
+type Run interface {
+	Context() context.Context
+}
+
+// LoxModule is an imported file. Its exports are the values of its exported
+// declarations once the file is executed.
+type LoxModule struct {
+	Path    string // absolute path of the file
+	Exports map[string]any
+}
+
+func (m *LoxModule) String() string {
+	return "<module: " + filepath.Base(m.Path) + ">"
+}
+
+// Get returns the export of m named identifier.
+func (m *LoxModule) Get(identifier golox.Token) (any, error) {
+	if val, ok := m.Exports[identifier.Lexeme]; !ok {
+		return nil, newErrorUndefinedExport(identifier, m)
+	} else {
+		return val, nil
+	}
+}
+
+// ImportModule returns the module imported by stmt in run with importer, which
+// can be nil if imports are not supported.
+func ImportModule(run Run, importer Importer, stmt *golox.StatementImport) (*LoxModule, error) {
+	if importer == nil {
+		return nil, NewErrorModuleNotFound(stmt.Path, nil)
+	}
+	return importer.Import(run, stmt)
+}
@@ -0,0 +1,44 @@
+package interpreter
+
+// NativeFunction is a LoxCallable implemented in Go.
+type NativeFunction struct {
+	name       string
+	arity      int // the minimum number of arguments if isVariadic
+	isVariadic bool
+	fn         func(args []any) (any, error)
+}
+
+func (fn *NativeFunction) String() string {
+	return "<native fn: " + fn.name + ">"
+}
+
+func (fn *NativeFunction) Name() string {
+	return fn.name
+}
+
+func (fn *NativeFunction) Arity() int {
+	return fn.arity
+}
+
+// IsVariadic reports whether fn accepts more than Arity() arguments.
+func (fn *NativeFunction) IsVariadic() bool {
+	return fn.isVariadic
+}
+
+func (fn *NativeFunction) Call(args []any) (any, error) {
+	return fn.fn(args)
+}
+
+func NewNativeFunction(
+	name string,
+	arity int,
+	isVariadic bool,
+	fn func(args []any) (any, error),
+) *NativeFunction {
+	return &NativeFunction{
+		name:       name,
+		arity:      arity,
+		isVariadic: isVariadic,
+		fn:         fn,
+	}
+}
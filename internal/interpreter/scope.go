@@ -1,15 +1,21 @@
 package interpreter
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
+// Scope stores the local variables of a block or a function call in the slots
+// assigned by the resolver. Global variables are stored by name in the
+// interpreter instead.
 type Scope struct {
-	NameToValue map[string]any
-	Enclosing   *Scope
+	Values    []any
+	Enclosing *Scope // nil for the global scope
 }
 
 // debug use
 func (s *Scope) level() int {
-	if s.Enclosing == nil {
+	if s == nil {
 		return 0
 	} else {
 		return 1 + s.Enclosing.level()
@@ -20,13 +26,13 @@ func (s *Scope) level() int {
 func (s *Scope) string() string {
 	var builder strings.Builder
 	builder.WriteByte('[')
-	i := 0
-	for identifier := range s.NameToValue {
-		if i != 0 {
-			builder.WriteString(", ")
+	if s != nil {
+		for i, val := range s.Values {
+			if i != 0 {
+				builder.WriteString(", ")
+			}
+			fmt.Fprint(&builder, val)
 		}
-		builder.WriteString(identifier)
-		i++
 	}
 	builder.WriteByte(']')
 	return builder.String()
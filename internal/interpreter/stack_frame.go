@@ -0,0 +1,49 @@
+package interpreter
+
+import (
+	"errors"
+	golox "golox/internal"
+)
+
+func (itp *Interpreter) beginFrame(callee LoxCallable, expr *golox.ExpressionCall) {
+	frame := golox.StackFrame{
+		CallSite: expr.GetLocation(),
+	}
+	switch callee := callee.(type) {
+	case *LoxFunction:
+		frame.FunctionName = callee.Declaration.Identifier.Lexeme
+		if callee.Class != nil {
+			frame.ClassName = callee.Class.Identifier.Lexeme
+		}
+	case *LoxClass:
+		frame.FunctionName = "init"
+		frame.ClassName = callee.Identifier.Lexeme
+	case *NativeFunction:
+		frame.FunctionName = callee.name
+	default:
+		frame.FunctionName = callee.String()
+	}
+	itp.run.frames = append(itp.run.frames, frame)
+}
+
+func (itp *Interpreter) endFrame() {
+	itp.run.frames = itp.run.frames[:len(itp.run.frames)-1]
+}
+
+// StackTrace returns the current call stack, with the most recent call first.
+func (itp *Interpreter) StackTrace() []golox.StackFrame {
+	trace := make([]golox.StackFrame, len(itp.run.frames))
+	for i, frame := range itp.run.frames {
+		trace[len(itp.run.frames)-1-i] = frame
+	}
+	return trace
+}
+
+// attachStackTrace attaches the current call stack to err if it is a runtime
+// error without a stack trace yet, i.e. it is raised in the innermost call.
+func (itp *Interpreter) attachStackTrace(err error) {
+	var diag *golox.Diagnostic
+	if errors.As(err, &diag) && diag.Phase == golox.PhaseRuntime && diag.StackTrace == nil {
+		diag.StackTrace = itp.StackTrace()
+	}
+}
@@ -2,21 +2,31 @@ package golox
 
 var (
 	Keywords = map[string]TokenType{
-		"var":    TokenTypeVar,
-		"nil":    TokenTypeNil,
-		"true":   TokenTypeTrue,
-		"false":  TokenTypeFalse,
-		"and":    TokenTypeAnd,
-		"or":     TokenTypeOr,
-		"if":     TokenTypeIf,
-		"else":   TokenTypeElse,
-		"for":    TokenTypeFor,
-		"while":  TokenTypeWhile,
-		"fun":    TokenTypeFun,
-		"return": TokenTypeReturn,
-		"class":  TokenTypeClass,
-		"super":  TokenTypeSuper,
-		"this":   TokenTypeThis,
-		"print":  TokenTypePrint,
+		"var":      TokenTypeVar,
+		"nil":      TokenTypeNil,
+		"true":     TokenTypeTrue,
+		"false":    TokenTypeFalse,
+		"and":      TokenTypeAnd,
+		"or":       TokenTypeOr,
+		"if":       TokenTypeIf,
+		"else":     TokenTypeElse,
+		"for":      TokenTypeFor,
+		"while":    TokenTypeWhile,
+		"fun":      TokenTypeFun,
+		"return":   TokenTypeReturn,
+		"class":    TokenTypeClass,
+		"super":    TokenTypeSuper,
+		"this":     TokenTypeThis,
+		"print":    TokenTypePrint,
+		"break":    TokenTypeBreak,
+		"continue": TokenTypeContinue,
+		"throw":    TokenTypeThrow,
+		"try":      TokenTypeTry,
+		"catch":    TokenTypeCatch,
+		"finally":  TokenTypeFinally,
+		"import":   TokenTypeImport,
+		"export":   TokenTypeExport,
+		"from":     TokenTypeFrom,
+		"as":       TokenTypeAs,
 	}
 )
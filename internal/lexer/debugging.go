@@ -0,0 +1,50 @@
+package lexer
+
+import (
+	golox "golox/internal"
+)
+
+func (l *Lexer) newErrorUnexpectedCharacter(
+	ch rune,
+) error {
+	end := l.location()
+	end.Col++
+	return golox.NewDiagnostic(
+		golox.PhaseLexer, golox.ErrorCodeUnexpectedCharacter,
+		l.location(), end,
+		"unexpected character '%c'", ch,
+	)
+}
+
+func (l *Lexer) newErrorUnterminatedString(
+	k int, // length of the string until the end of source
+) error {
+	leftQuote := golox.Token{
+		Location: l.location(),
+		Lexeme:   l.lexeme(1),
+	}
+	eof := golox.Token{
+		Location: l.location(),
+		Lexeme:   l.lexeme(k),
+	}.End()
+	return golox.NewDiagnostic(
+		golox.PhaseLexer, golox.ErrorCodeUnterminatedString,
+		eof, eof,
+		"unterminated string",
+	).WithLabel(leftQuote.Location, leftQuote.End(), "string started here")
+}
+
+func (l *Lexer) newErrorInvalidNumber(
+	k int, // length of the number
+	err error,
+) error {
+	end := l.location()
+	end.Col += k
+	diag := golox.NewDiagnostic(
+		golox.PhaseLexer, golox.ErrorCodeInvalidNumber,
+		l.location(), end,
+		"invalid numeric string: %s", err.Error(),
+	)
+	diag.Err = err
+	return diag
+}
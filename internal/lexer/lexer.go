@@ -10,6 +10,7 @@ type Lexer struct {
 	srcPath         string        // input
 	tokens          []golox.Token // output
 	curr, line, col int
+	logger          *golox.Logger
 }
 
 func (l *Lexer) lookAhead(k int) (rune, bool) {
@@ -21,8 +22,14 @@ func (l *Lexer) lookAhead(k int) (rune, bool) {
 }
 
 func (l *Lexer) advance(k int) {
-	l.curr += k
-	l.col += k
+	for i := 0; i < k; i++ {
+		if ch, ok := l.lookAhead(0); ok && ch == '\n' {
+			l.newline()
+		} else {
+			l.col++
+		}
+		l.curr++
+	}
 }
 
 func (l *Lexer) lexeme(k int) string {
@@ -38,19 +45,23 @@ func (l *Lexer) newline() {
 	l.col = 1
 }
 
+func (l *Lexer) location() golox.Location {
+	return golox.Location{
+		SrcPath: l.srcPath,
+		Line:    l.line,
+		Col:     l.col,
+	}
+}
+
 func (l *Lexer) consumeAsToken(k int, tokenType golox.TokenType, literalValue any) {
 	tkn := golox.Token{
-		Location: golox.Location{
-			SrcPath: l.srcPath,
-			Line:    l.line,
-			Col:     l.col,
-		},
+		Location:     l.location(),
 		TokenType:    tokenType,
 		LiteralValue: literalValue,
 		Lexeme:       l.lexeme(k),
 	}
 	l.tokens = append(l.tokens, tkn)
-	golox.Logf(
+	l.logger.Logf(
 		golox.ModuleLexer,
 		"%s:%d:%d: '%s' (%s)",
 		l.srcPath, l.line, l.col, tkn.Lexeme, tkn.TokenType,
@@ -77,10 +88,7 @@ func (l *Lexer) TokensFromSource(source []rune, srcPath string) ([]golox.Token,
 			break
 		} else {
 			switch ch {
-			case '\n':
-				l.advance(1)
-				l.newline()
-			case ' ', '\r', '\t':
+			case '\n', ' ', '\r', '\t':
 				l.advance(1)
 			case '(':
 				l.consumeAsToken(1, golox.TokenTypeLeftParen, nil)
@@ -90,12 +98,18 @@ func (l *Lexer) TokensFromSource(source []rune, srcPath string) ([]golox.Token,
 				l.consumeAsToken(1, golox.TokenTypeLeftBrace, nil)
 			case '}':
 				l.consumeAsToken(1, golox.TokenTypeRightBrace, nil)
+			case '[':
+				l.consumeAsToken(1, golox.TokenTypeLeftBracket, nil)
+			case ']':
+				l.consumeAsToken(1, golox.TokenTypeRightBracket, nil)
 			case ',':
 				l.consumeAsToken(1, golox.TokenTypeComma, nil)
 			case '.':
 				l.consumeAsToken(1, golox.TokenTypeDot, nil)
 			case ';':
 				l.consumeAsToken(1, golox.TokenTypeSemicolon, nil)
+			case ':':
+				l.consumeAsToken(1, golox.TokenTypeColon, nil)
 			case '+':
 				l.consumeAsToken(1, golox.TokenTypePlus, nil)
 			case '-':
@@ -112,8 +126,7 @@ func (l *Lexer) TokensFromSource(source []rune, srcPath string) ([]golox.Token,
 							l.advance(1)
 						}
 					}
-					l.advance(1)
-					l.newline()
+					l.advance(1) // skip the newline
 				} else {
 					l.consumeAsToken(1, golox.TokenTypeSlash, nil)
 				}
@@ -126,6 +139,8 @@ func (l *Lexer) TokensFromSource(source []rune, srcPath string) ([]golox.Token,
 			case '=':
 				if ch, ok := l.lookAhead(1); ok && ch == '=' {
 					l.consumeAsToken(2, golox.TokenTypeEqualEqual, nil)
+				} else if ok && ch == '>' {
+					l.consumeAsToken(2, golox.TokenTypeArrow, nil)
 				} else {
 					l.consumeAsToken(1, golox.TokenTypeEqual, nil)
 				}
@@ -142,22 +157,12 @@ func (l *Lexer) TokensFromSource(source []rune, srcPath string) ([]golox.Token,
 					l.consumeAsToken(1, golox.TokenTypeGreater, nil)
 				}
 			case '"':
-				leftQuoteLine, leftQuoteCol := l.line, l.col
 				k := 1
 				for ; ; k++ {
 					if ch, ok := l.lookAhead(k); !ok {
-						return nil, golox.NewErrorf(
-							golox.Location{
-								SrcPath: l.srcPath,
-								Line:    l.line,
-								Col:     l.col,
-							},
-							"unterminated string, started at line %d:%d",
-							leftQuoteLine, leftQuoteCol)
+						return nil, l.newErrorUnterminatedString(k)
 					} else if ch == '"' {
 						break
-					} else if ch == '\n' {
-						l.newline()
 					}
 				}
 				l.consumeAsToken(k+1, golox.TokenTypeString, string(l.lexeme(k)[1:]))
@@ -182,14 +187,7 @@ func (l *Lexer) TokensFromSource(source []rune, srcPath string) ([]golox.Token,
 						}
 					}
 					if val, err := strconv.ParseFloat(l.lexeme(k), 64); err != nil {
-						return nil, golox.NewErrorf(
-							golox.Location{
-								SrcPath: l.srcPath,
-								Line:    l.line,
-								Col:     l.col,
-							},
-							"invalid numeric string: %s", err.Error(),
-						)
+						return nil, l.newErrorInvalidNumber(k, err)
 					} else {
 						l.consumeAsToken(k, golox.TokenTypeNumber, val)
 					}
@@ -206,14 +204,7 @@ func (l *Lexer) TokensFromSource(source []rune, srcPath string) ([]golox.Token,
 						l.consumeAsToken(k, golox.TokenTypeIdentifier, nil)
 					}
 				default:
-					return nil, golox.NewErrorf(
-						golox.Location{
-							SrcPath: l.srcPath,
-							Line:    l.line,
-							Col:     l.col,
-						},
-						"unexpected character '%c'", ch,
-					)
+					return nil, l.newErrorUnexpectedCharacter(ch)
 				}
 			}
 		}
@@ -222,6 +213,8 @@ func (l *Lexer) TokensFromSource(source []rune, srcPath string) ([]golox.Token,
 	return l.tokens, nil
 }
 
-func NewLexer() *Lexer {
-	return &Lexer{}
+func NewLexer(logger *golox.Logger) *Lexer {
+	return &Lexer{
+		logger: logger,
+	}
 }
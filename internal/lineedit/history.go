@@ -0,0 +1,120 @@
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxHistory is the number of lines kept in a history, the oldest lines are
+// dropped first.
+const MaxHistory = 1000
+
+// History is the lines read by an Editor, the oldest first. The lines of a
+// history with a file are appended to the file, to be loaded by the next
+// sessions.
+type History struct {
+	lines []string
+	path  string // "" for a history kept in memory
+}
+
+// DefaultHistoryPath returns the file of the history of interactive sessions,
+// in the config directory of the user.
+func DefaultHistoryPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "golox", "history"), nil
+}
+
+// NewHistory returns an empty history kept in memory.
+func NewHistory() *History {
+	return &History{
+		lines: nil,
+		path:  "",
+	}
+}
+
+// LoadHistory returns the history of the file at path, which is created by
+// the first added line if it does not exist.
+func LoadHistory(path string) (*History, error) {
+	h := &History{
+		lines: nil,
+		path:  path,
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.lines = append(h.lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// the file is only rewritten when it is too long, lines are appended
+	// otherwise
+	if len(h.lines) > MaxHistory {
+		h.lines = h.lines[len(h.lines)-MaxHistory:]
+		if err := h.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// Lines returns the lines of h, the oldest first.
+func (h *History) Lines() []string {
+	return h.lines
+}
+
+// Add appends line to h, unless it is blank or repeats the last line.
+func (h *History) Add(line string) error {
+	if strings.TrimSpace(line) == "" {
+		return nil
+	} else if len(h.lines) > 0 && h.lines[len(h.lines)-1] == line {
+		return nil
+	}
+
+	h.lines = append(h.lines, line)
+	if len(h.lines) > MaxHistory {
+		h.lines = h.lines[1:]
+	}
+	if h.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (h *History) rewrite() error {
+	var b strings.Builder
+	for _, line := range h.lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return os.WriteFile(h.path, []byte(b.String()), 0o600)
+}
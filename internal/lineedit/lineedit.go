@@ -0,0 +1,401 @@
+// Package lineedit reads lines from a terminal with the editing keys of a
+// shell: moving the cursor, browsing a history, searching it backwards
+// (ctrl+r) and completing words (tab).
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// ErrInterrupted is returned by ReadLine when the line is discarded with
+// ctrl+c.
+var ErrInterrupted = errors.New("interrupted")
+
+// Completer returns the candidates completing the word that ends the line
+// before the cursor, and the index in line where that word starts.
+type Completer func(line []rune) (candidates []string, start int)
+
+type Editor struct {
+	in        io.Reader
+	reader    *bufio.Reader
+	out       io.Writer
+	history   *History
+	completer Completer // nil for no completions
+}
+
+// key is a rune typed in the terminal, or one of the keys below sent as an
+// escape sequence.
+type key rune
+
+const (
+	keyCtrlA     key = 1
+	keyCtrlB     key = 2
+	keyCtrlC     key = 3
+	keyCtrlD     key = 4
+	keyCtrlE     key = 5
+	keyCtrlF     key = 6
+	keyCtrlG     key = 7
+	keyCtrlH     key = 8
+	keyTab       key = 9
+	keyLineFeed  key = 10
+	keyCtrlK     key = 11
+	keyCtrlL     key = 12
+	keyEnter     key = 13
+	keyCtrlN     key = 14
+	keyCtrlP     key = 16
+	keyCtrlR     key = 18
+	keyCtrlU     key = 21
+	keyCtrlW     key = 23
+	keyEscape    key = 27
+	keyBackspace key = 127
+
+	// escape sequences, out of the range of runes:
+	keyUp key = -iota
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyDelete
+	keyUnknown
+)
+
+// NewEditor returns an editor reading the keys typed in in, and drawing the
+// lines on out. in is put in raw mode while a line is read, if it is a
+// terminal. The lines read are added to history.
+func NewEditor(in io.Reader, out io.Writer, history *History, completer Completer) *Editor {
+	return &Editor{
+		in:        in,
+		reader:    bufio.NewReader(in),
+		out:       out,
+		history:   history,
+		completer: completer,
+	}
+}
+
+// line is the state of the line being edited.
+type line struct {
+	prompt string
+	buf    []rune
+	pos    int    // cursor, as an index of buf
+	index  int    // of the history line shown, len(lines) for the new line
+	draft  []rune // the new line, while a history line is shown
+}
+
+// ReadLine prints prompt and returns the line typed after it, which is added
+// to the history. It returns io.EOF for ctrl+d on an empty line, and
+// ErrInterrupted for ctrl+c.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	restore, err := makeRaw(e.in)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	l := &line{
+		prompt: prompt,
+		buf:    nil,
+		pos:    0,
+		index:  len(e.history.Lines()),
+		draft:  nil,
+	}
+	e.refresh(l)
+	for {
+		k, err := e.readKey()
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(l.buf) > 0 {
+				// the last line of the input is not terminated
+				e.write("\r\n")
+				return e.submit(l)
+			}
+			return "", err
+		}
+		if k == keyCtrlR {
+			if k, err = e.search(l); err != nil {
+				return "", err
+			}
+		}
+
+		switch k {
+		case keyEnter, keyLineFeed:
+			e.write("\r\n")
+			return e.submit(l)
+		case keyCtrlC:
+			e.write("^C\r\n")
+			return "", ErrInterrupted
+		case keyCtrlD:
+			if len(l.buf) == 0 {
+				return "", io.EOF
+			}
+			l.delete(l.pos, l.pos+1)
+		case keyBackspace, keyCtrlH:
+			l.delete(l.pos-1, l.pos)
+		case keyDelete:
+			l.delete(l.pos, l.pos+1)
+		case keyLeft, keyCtrlB:
+			l.pos = max(l.pos-1, 0)
+		case keyRight, keyCtrlF:
+			l.pos = min(l.pos+1, len(l.buf))
+		case keyHome, keyCtrlA:
+			l.pos = 0
+		case keyEnd, keyCtrlE:
+			l.pos = len(l.buf)
+		case keyCtrlK:
+			l.delete(l.pos, len(l.buf))
+		case keyCtrlU:
+			l.delete(0, l.pos)
+		case keyCtrlW:
+			l.delete(wordStart(l.buf, l.pos), l.pos)
+		case keyUp, keyCtrlP:
+			e.browse(l, l.index-1)
+		case keyDown, keyCtrlN:
+			e.browse(l, l.index+1)
+		case keyTab:
+			e.complete(l)
+		case keyCtrlL:
+			e.write("\x1b[H\x1b[2J")
+		default:
+			if k >= ' ' {
+				l.insert([]rune{rune(k)})
+			}
+		}
+		e.refresh(l)
+	}
+}
+
+func (e *Editor) submit(l *line) (string, error) {
+	s := string(l.buf)
+	// a history file that cannot be written does not prevent editing
+	_ = e.history.Add(s)
+	return s, nil
+}
+
+// readKey returns the next key, decoding the escape sequences of the arrows
+// and of the home, end and delete keys.
+func (e *Editor) readKey() (key, error) {
+	r, _, err := e.reader.ReadRune()
+	if err != nil {
+		return 0, err
+	} else if key(r) != keyEscape {
+		return key(r), nil
+	}
+
+	// a lone escape is only followed by another key
+	r, _, err = e.reader.ReadRune()
+	if err != nil {
+		return 0, err
+	} else if r != '[' && r != 'O' {
+		return keyUnknown, nil
+	}
+
+	var seq []rune
+	for {
+		r, _, err = e.reader.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		seq = append(seq, r)
+		if (r < '0' || r > '9') && r != ';' {
+			break
+		}
+	}
+	switch string(seq) {
+	case "A":
+		return keyUp, nil
+	case "B":
+		return keyDown, nil
+	case "C":
+		return keyRight, nil
+	case "D":
+		return keyLeft, nil
+	case "H", "1~", "7~":
+		return keyHome, nil
+	case "F", "4~", "8~":
+		return keyEnd, nil
+	case "3~":
+		return keyDelete, nil
+	default:
+		return keyUnknown, nil
+	}
+}
+
+// browse shows the history line at index in l, the new line being kept as a
+// draft.
+func (e *Editor) browse(l *line, index int) {
+	lines := e.history.Lines()
+	if index < 0 || index > len(lines) {
+		return
+	}
+	if l.index == len(lines) {
+		l.draft = l.buf
+	}
+	l.index = index
+	if index == len(lines) {
+		l.buf = l.draft
+	} else {
+		l.buf = []rune(lines[index])
+	}
+	l.pos = len(l.buf)
+}
+
+// search searches the history backwards for the lines containing the typed
+// query, until a key other than the query's is typed. The line found is kept
+// in l, and that key is returned to be handled.
+func (e *Editor) search(l *line) (key, error) {
+	lines := e.history.Lines()
+	buf, pos := l.buf, l.pos
+	var query []rune
+	index := len(lines) // of the line found
+	find := func(from int) {
+		for i := min(from, len(lines)-1); i >= 0; i-- {
+			if at := strings.Index(lines[i], string(query)); at >= 0 {
+				index = i
+				buf = []rune(lines[i])
+				pos = len([]rune(lines[i][:at]))
+				return
+			}
+		}
+	}
+
+	for {
+		e.write("\r(reverse-i-search)`" + string(query) + "': " + string(buf) + "\x1b[K")
+		k, err := e.readKey()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case k == keyCtrlR:
+			find(index - 1)
+		case k == keyBackspace || k == keyCtrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				find(len(lines) - 1)
+			}
+		case k == keyCtrlG:
+			// cancelled, the line is left as it was
+			return keyUnknown, nil
+		case k >= ' ':
+			query = append(query, rune(k))
+			find(index)
+		default:
+			if index < len(lines) {
+				if l.index == len(lines) {
+					l.draft = l.buf
+				}
+				l.index, l.buf, l.pos = index, buf, pos
+			}
+			return k, nil
+		}
+	}
+}
+
+// complete completes the word before the cursor with the longest prefix of its
+// candidates, and lists them if there are several.
+func (e *Editor) complete(l *line) {
+	if e.completer == nil {
+		return
+	}
+	candidates, start := e.completer(l.buf[:l.pos])
+	if len(candidates) == 0 {
+		e.write("\a")
+		return
+	}
+
+	prefix := []rune(candidates[0])
+	for _, c := range candidates[1:] {
+		prefix = commonPrefix(prefix, []rune(c))
+	}
+	if len(prefix) > l.pos-start {
+		l.delete(start, l.pos)
+		l.insert(prefix)
+	} else if len(candidates) > 1 {
+		e.write("\r\n" + strings.Join(candidates, "  ") + "\r\n")
+	}
+}
+
+// refresh draws l over the current line of the terminal.
+func (e *Editor) refresh(l *line) {
+	var b strings.Builder
+	b.WriteString("\r")
+	b.WriteString(l.prompt)
+	b.WriteString(string(l.buf))
+	b.WriteString("\x1b[K")
+	if n := len(l.buf) - l.pos; n > 0 {
+		fmt.Fprintf(&b, "\x1b[%dD", n)
+	}
+	e.write(b.String())
+}
+
+func (e *Editor) write(s string) {
+	io.WriteString(e.out, s)
+}
+
+// insert inserts runes at the cursor of l.
+func (l *line) insert(runes []rune) {
+	buf := make([]rune, 0, len(l.buf)+len(runes))
+	buf = append(buf, l.buf[:l.pos]...)
+	buf = append(buf, runes...)
+	buf = append(buf, l.buf[l.pos:]...)
+	l.buf = buf
+	l.pos += len(runes)
+}
+
+// delete deletes the runes of l from start to end, which are clamped to the
+// line.
+func (l *line) delete(start, end int) {
+	start, end = max(start, 0), min(end, len(l.buf))
+	if start >= end {
+		return
+	}
+	buf := make([]rune, 0, len(l.buf)-(end-start))
+	buf = append(buf, l.buf[:start]...)
+	buf = append(buf, l.buf[end:]...)
+	l.buf = buf
+	if l.pos > end {
+		l.pos -= end - start
+	} else if l.pos > start {
+		l.pos = start
+	}
+}
+
+// wordStart returns the start of the word before pos in buf, after the spaces
+// ending it.
+func wordStart(buf []rune, pos int) int {
+	i := pos
+	for i > 0 && unicode.IsSpace(buf[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(buf[i-1]) {
+		i--
+	}
+	return i
+}
+
+func commonPrefix(a, b []rune) []rune {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
+
+// the builtins min and max need Go 1.21.
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package lineedit
+
+import "io"
+
+// IsTerminal reports whether r is a terminal, whose lines can be edited. The
+// terminals of this platform are not supported.
+func IsTerminal(r io.Reader) bool {
+	return false
+}
+
+func makeRaw(r io.Reader) (func(), error) {
+	return func() {}, nil
+}
@@ -2,6 +2,7 @@ package golox
 
 import (
 	"fmt"
+	"io"
 	"log"
 )
 
@@ -13,18 +14,38 @@ const (
 	ModuleParser      Module = iota
 	ModuleResolver    Module = iota
 	ModuleInterpreter Module = iota
+	ModuleCompiler    Module = iota
+	ModuleVM          Module = iota
 )
 
-func Logf(module Module, format string, args ...any) {
-	if ConfigIsDebug {
+type Logger struct {
+	isDebug bool
+	logger  *log.Logger
+}
+
+func (l *Logger) Logf(module Module, format string, args ...any) {
+	if l.isDebug {
 		if format == "" {
-			log.Printf("DEBUG[%s]",
+			l.logger.Printf("DEBUG[%s]",
 				module,
 			)
 		} else {
-			log.Printf("DEBUG[%s] %s",
+			l.logger.Printf("DEBUG[%s] %s",
 				module, fmt.Sprintf(format, args...),
 			)
 		}
 	}
 }
+
+// SetDebug enables or disables the debug logs.
+func (l *Logger) SetDebug(isDebug bool) {
+	l.isDebug = isDebug
+}
+
+// NewLogger returns a Logger writing debug logs to w if isDebug is true.
+func NewLogger(isDebug bool, w io.Writer) *Logger {
+	return &Logger{
+		isDebug: isDebug,
+		logger:  log.New(w, "", 0),
+	}
+}
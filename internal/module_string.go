@@ -12,11 +12,13 @@ func _() {
 	_ = x[ModuleParser-1]
 	_ = x[ModuleResolver-2]
 	_ = x[ModuleInterpreter-3]
+	_ = x[ModuleCompiler-4]
+	_ = x[ModuleVM-5]
 }
 
-const _Module_name = "ModuleLexerModuleParserModuleResolverModuleInterpreter"
+const _Module_name = "ModuleLexerModuleParserModuleResolverModuleInterpreterModuleCompilerModuleVM"
 
-var _Module_index = [...]uint8{0, 11, 23, 37, 54}
+var _Module_index = [...]uint8{0, 11, 23, 37, 54, 68, 76}
 
 func (i Module) String() string {
 	if i < 0 || i >= Module(len(_Module_index)-1) {
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	golox "golox/internal"
+)
+
+func (p *Parser) newErrorUnexpectedToken(
+	tkn golox.Token,
+	message string, // e.g. "expect ';' after expression"
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseParser, golox.ErrorCodeUnexpectedToken,
+		tkn,
+		"%s", message,
+	)
+}
+
+func (p *Parser) newErrorUnexpectedDeclaration(
+	tkn golox.Token,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseParser, golox.ErrorCodeUnexpectedDeclaration,
+		tkn,
+		"expect statement but not declaration",
+	)
+}
+
+func (p *Parser) newErrorUnclosedBlock(
+	eofTkn golox.Token,
+	leftBrace golox.Token,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseParser, golox.ErrorCodeUnclosedBlock,
+		eofTkn,
+		"missing closing '}'",
+	).WithLabel(leftBrace.Location, leftBrace.End(), "block opened here")
+}
+
+func (p *Parser) newErrorTooManyParameters(
+	tkn golox.Token,
+	fnIdentifier golox.Token,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseParser, golox.ErrorCodeTooManyParameters,
+		tkn,
+		"function '%s' cannot have more than 255 parameters", fnIdentifier.Lexeme,
+	)
+}
+
+func (p *Parser) newErrorTooManyArguments(
+	tkn golox.Token,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseParser, golox.ErrorCodeTooManyArguments,
+		tkn,
+		"function call cannot have more than 255 arguments",
+	)
+}
+
+func (p *Parser) newErrorInvalidAssignmentTarget(
+	equalTkn golox.Token,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseParser, golox.ErrorCodeInvalidAssignmentTarget,
+		equalTkn,
+		"invalid assignment lvalue",
+	)
+}
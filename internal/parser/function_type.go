@@ -5,4 +5,5 @@ type FunctionType int
 const (
 	FunctionTypeFunction FunctionType = iota
 	FunctionTypeMethod
+	FunctionTypeAnonymous
 )
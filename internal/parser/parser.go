@@ -3,7 +3,7 @@ package parser
 import (
 	"fmt"
 	golox "golox/internal"
-	"strconv"
+	"path/filepath"
 	"strings"
 )
 
@@ -11,7 +11,8 @@ type Parser struct {
 	tokens []golox.Token     // input
 	stmts  []golox.Statement // output
 	curr   int               // index to current token
-	errors []error
+	errors golox.Diagnostics
+	logger *golox.Logger
 }
 
 func (p *Parser) peekTokenType() golox.TokenType {
@@ -22,6 +23,15 @@ func (p *Parser) peekTokenType() golox.TokenType {
 	}
 }
 
+// peekNextTokenType returns the type of the token after the current one.
+func (p *Parser) peekNextTokenType() golox.TokenType {
+	if p.curr+1 >= len(p.tokens) {
+		return golox.TokenTypeEOF
+	} else {
+		return p.tokens[p.curr+1].TokenType
+	}
+}
+
 func (p *Parser) skipToken() golox.Token {
 	tkn := p.tokens[p.curr]
 	p.curr++
@@ -46,9 +56,19 @@ func (p *Parser) parseDeclaration() (golox.Statement, error) {
 	case golox.TokenTypeVar:
 		stmt, err = p.statementVar()
 	case golox.TokenTypeFun:
-		stmt, err = p.statementFun(FunctionTypeFunction)
+		if p.peekNextTokenType() == golox.TokenTypeLeftParen {
+			stmt, err = p.parseStatement() // an anonymous function in an expression statement
+		} else {
+			stmt, err = p.statementFun(FunctionTypeFunction)
+		}
 	case golox.TokenTypeClass:
 		stmt, err = p.statementClass()
+	case golox.TokenTypeImport:
+		stmt, err = p.statementImport()
+	case golox.TokenTypeFrom:
+		stmt, err = p.statementFromImport()
+	case golox.TokenTypeExport:
+		stmt, err = p.statementExport()
 	default:
 		stmt, err = p.parseStatement()
 	}
@@ -69,7 +89,14 @@ func (p *Parser) parseDeclaration() (golox.Statement, error) {
 				golox.TokenTypeFun,
 				golox.TokenTypeReturn,
 				golox.TokenTypeClass,
-				golox.TokenTypePrint:
+				golox.TokenTypePrint,
+				golox.TokenTypeBreak,
+				golox.TokenTypeContinue,
+				golox.TokenTypeThrow,
+				golox.TokenTypeTry,
+				golox.TokenTypeImport,
+				golox.TokenTypeFrom,
+				golox.TokenTypeExport:
 				goto L_SYNCHRONIZE_END
 			default:
 				_ = p.skipToken()
@@ -83,14 +110,19 @@ L_SYNCHRONIZE_END:
 
 func (p *Parser) parseStatement() (golox.Statement, error) {
 	switch p.peekTokenType() {
+	case golox.TokenTypeFun:
+		if p.peekNextTokenType() == golox.TokenTypeLeftParen {
+			return p.statementExpression() // an anonymous function
+		}
+		tkn := p.skipToken()
+		return nil, p.newErrorUnexpectedDeclaration(tkn)
 	case golox.TokenTypeVar,
-		golox.TokenTypeFun,
-		golox.TokenTypeClass:
+		golox.TokenTypeClass,
+		golox.TokenTypeImport,
+		golox.TokenTypeFrom,
+		golox.TokenTypeExport:
 		tkn := p.skipToken()
-		return nil, golox.NewErrorf(
-			tkn.Location,
-			"expect statement but not declaration",
-		)
+		return nil, p.newErrorUnexpectedDeclaration(tkn)
 	case golox.TokenTypeEOF:
 		return nil, nil
 	case golox.TokenTypeLeftBrace:
@@ -103,6 +135,14 @@ func (p *Parser) parseStatement() (golox.Statement, error) {
 		return p.statementFor()
 	case golox.TokenTypeReturn:
 		return p.statementReturn()
+	case golox.TokenTypeBreak:
+		return p.statementBreak()
+	case golox.TokenTypeContinue:
+		return p.statementContinue()
+	case golox.TokenTypeThrow:
+		return p.statementThrow()
+	case golox.TokenTypeTry:
+		return p.statementTry()
 	case golox.TokenTypePrint:
 		return p.statementPrint()
 	default:
@@ -117,9 +157,11 @@ func (p *Parser) statementBlock() (*golox.StatementBlock, error) {
 		Statements: []golox.Statement{},
 	}
 
+	var leftBrace golox.Token
 	if tkn, ok := p.expectTokenType(golox.TokenTypeLeftBrace); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect block statement")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect block statement")
 	} else {
+		leftBrace = tkn
 		result.Location = tkn.Location
 	}
 
@@ -127,11 +169,7 @@ func (p *Parser) statementBlock() (*golox.StatementBlock, error) {
 		switch p.peekTokenType() {
 		case golox.TokenTypeEOF:
 			tkn := p.skipToken()
-			return nil, golox.NewErrorf(
-				tkn.Location,
-				"missing closing '}', started at line %d:%d",
-				result.Location.Line, result.Location.Col,
-			)
+			return nil, p.newErrorUnclosedBlock(tkn, leftBrace)
 		case golox.TokenTypeRightBrace:
 			_ = p.skipToken()
 			return result, nil
@@ -158,7 +196,7 @@ func (p *Parser) statementExpression() (*golox.StatementExpression, error) {
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeSemicolon); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect ';' after expression")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect ';' after expression")
 	}
 
 	return result, nil
@@ -173,13 +211,13 @@ func (p *Parser) statementVar() (*golox.StatementVar, error) {
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeVar); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect 'var' keyword")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'var' keyword")
 	} else {
 		result.VarToken = tkn
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeIdentifier); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect identifier after 'var'")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect identifier after 'var'")
 	} else {
 		result.Identifier = tkn
 	}
@@ -195,7 +233,7 @@ func (p *Parser) statementVar() (*golox.StatementVar, error) {
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeSemicolon); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect ';' after var statement")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect ';' after var statement")
 	}
 
 	return result, nil
@@ -211,13 +249,13 @@ func (p *Parser) statementIf() (*golox.StatementIf, error) {
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeIf); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect 'if' keyword")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'if' keyword")
 	} else {
 		result.IfToken = tkn
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeLeftParen); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect '(' after 'if'")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect '(' after 'if'")
 	}
 
 	if expr, err := p.parseExpression(); err != nil {
@@ -227,7 +265,7 @@ func (p *Parser) statementIf() (*golox.StatementIf, error) {
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeRightParen); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect ')' after if condition")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect ')' after if condition")
 	}
 
 	if stmt, err := p.parseStatement(); err != nil {
@@ -255,16 +293,17 @@ func (p *Parser) statementWhile() (*golox.StatementWhile, error) {
 		WhileToken: golox.Token{},
 		Condition:  nil,
 		Body:       nil,
+		Increment:  nil,
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeWhile); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect 'while' keyword")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'while' keyword")
 	} else {
 		result.WhileToken = tkn
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeLeftParen); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect '(' after 'while'")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect '(' after 'while'")
 	}
 
 	if expr, err := p.parseExpression(); err != nil {
@@ -274,7 +313,7 @@ func (p *Parser) statementWhile() (*golox.StatementWhile, error) {
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeRightParen); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect ')' after while condition")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect ')' after while condition")
 	}
 
 	if stmt, err := p.parseStatement(); err != nil {
@@ -291,13 +330,13 @@ func (p *Parser) statementFor() (golox.Statement, error) {
 
 	var forToken golox.Token
 	if tkn, ok := p.expectTokenType(golox.TokenTypeFor); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect 'for' keyword")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'for' keyword")
 	} else {
 		forToken = tkn
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeLeftParen); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect '(' after 'for'")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect '(' after 'for'")
 	}
 
 	var initializer golox.Statement
@@ -338,12 +377,11 @@ func (p *Parser) statementFor() (golox.Statement, error) {
 		}
 
 		if tkn, ok := p.expectTokenType(golox.TokenTypeSemicolon); !ok {
-			return nil, golox.NewErrorf(tkn.Location, "expect ';' after loop condition")
+			return nil, p.newErrorUnexpectedToken(tkn, "expect ';' after loop condition")
 		}
 	}
 
 	var increment golox.Expression
-	hasIncrement := false
 	switch p.peekTokenType() {
 	case golox.TokenTypeRightParen:
 		_ = p.skipToken()
@@ -352,11 +390,10 @@ func (p *Parser) statementFor() (golox.Statement, error) {
 			return nil, err
 		} else {
 			increment = expr
-			hasIncrement = true
 		}
 
 		if tkn, ok := p.expectTokenType(golox.TokenTypeRightParen); !ok {
-			return nil, golox.NewErrorf(tkn.Location, "expect ')' after for clause")
+			return nil, p.newErrorUnexpectedToken(tkn, "expect ')' after for clause")
 		}
 	}
 
@@ -373,25 +410,16 @@ func (p *Parser) statementFor() (golox.Statement, error) {
 	//     INITIALIZER;
 	//     while(CONDITION) {
 	//         BODY;  // BODY could be a single statement or a block statement
-	//         INCREMENT;
+	//         INCREMENT;  // kept in the while statement, so that continue runs it
 	//     }
 	// }
 	var result golox.Statement
 
-	if hasIncrement {
-		body = &golox.StatementBlock{
-			Location: body.GetLocation(),
-			Statements: []golox.Statement{
-				body,
-				&golox.StatementExpression{Expression: increment},
-			},
-		}
-	}
-
 	result = &golox.StatementWhile{
 		WhileToken: forToken,
 		Condition:  condition,
 		Body:       body,
+		Increment:  increment,
 	}
 
 	if hasInitializer {
@@ -405,7 +433,7 @@ func (p *Parser) statementFor() (golox.Statement, error) {
 }
 
 func (p *Parser) statementFun(fnType FunctionType) (*golox.StatementFun, error) {
-	// matching: "fun"? IDENTIFIER "(" (PARAMETER ("," PARAMETER))? ")" STATEMENT_BLOCK
+	// matching: "fun"? IDENTIFIER? "(" (PARAMETER ("," PARAMETER))? ")" STATEMENT_BLOCK
 	result := &golox.StatementFun{
 		FunToken:   golox.Token{},
 		Identifier: golox.Token{},
@@ -416,58 +444,139 @@ func (p *Parser) statementFun(fnType FunctionType) (*golox.StatementFun, error)
 	switch fnType {
 	case FunctionTypeFunction:
 		if tkn, ok := p.expectTokenType(golox.TokenTypeFun); !ok {
-			return nil, golox.NewErrorf(tkn.Location, "expect 'fun' keyword")
+			return nil, p.newErrorUnexpectedToken(tkn, "expect 'fun' keyword")
 		} else {
 			result.FunToken = tkn
 		}
 	case FunctionTypeMethod:
 		break
+	case FunctionTypeAnonymous:
+		if tkn, ok := p.expectTokenType(golox.TokenTypeFun); !ok {
+			return nil, p.newErrorUnexpectedToken(tkn, "expect 'fun' keyword")
+		} else {
+			result.FunToken = tkn
+			result.Identifier = anonymousIdentifier(tkn)
+		}
 	}
 
-	if tkn, ok := p.expectTokenType(golox.TokenTypeIdentifier); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect function name")
-	} else {
-		result.Identifier = tkn
+	if fnType != FunctionTypeAnonymous {
+		if tkn, ok := p.expectTokenType(golox.TokenTypeIdentifier); !ok {
+			return nil, p.newErrorUnexpectedToken(tkn, "expect function name")
+		} else {
+			result.Identifier = tkn
+		}
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeLeftParen); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect '(' after function name")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect '(' after function name")
 	}
 
+	if params, err := p.functionParameters(result.Identifier); err != nil {
+		return nil, err
+	} else {
+		result.Parameters = params
+	}
+
+	if stmt, err := p.statementBlock(); err != nil {
+		return nil, err
+	} else {
+		result.Body = stmt.Statements
+	}
+
+	return result, nil
+}
+
+// functionParameters parses the parameters of the function named identifier,
+// after its "(".
+func (p *Parser) functionParameters(identifier golox.Token) ([]golox.Token, error) {
+	// matching: (PARAMETER ("," PARAMETER))? ")"
+	params := []golox.Token{}
+
 	if p.peekTokenType() != golox.TokenTypeRightParen {
 		for {
 			if tkn, ok := p.expectTokenType(golox.TokenTypeIdentifier); !ok {
-				return nil, golox.NewErrorf(tkn.Location, "expect parameter name after ','")
+				return nil, p.newErrorUnexpectedToken(tkn, "expect parameter name after ','")
 			} else {
-				result.Parameters = append(result.Parameters, tkn)
+				params = append(params, tkn)
 			}
 
 			if p.peekTokenType() != golox.TokenTypeComma {
 				break
 			} else {
 				tkn := p.skipToken()
-				if len(result.Parameters) >= 255 {
-					return nil, golox.NewErrorf(
-						tkn.Location,
-						"function '%s' cannot have more than 255 parameters",
-						result.Identifier.Lexeme,
-					)
+				if len(params) >= 255 {
+					return nil, p.newErrorTooManyParameters(tkn, identifier)
 				}
 			}
 		}
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeRightParen); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect ')' after function parameters")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect ')' after function parameters")
 	}
 
-	if stmt, err := p.statementBlock(); err != nil {
+	return params, nil
+}
+
+// anonymousIdentifier returns the name of an anonymous function starting at
+// tkn, e.g. anonymous@main.lox:3.
+func anonymousIdentifier(tkn golox.Token) golox.Token {
+	return golox.Token{
+		Location:     tkn.Location,
+		TokenType:    golox.TokenTypeIdentifier,
+		LiteralValue: nil,
+		Lexeme:       fmt.Sprintf("anonymous@%s:%d", filepath.Base(tkn.SrcPath), tkn.Line),
+	}
+}
+
+// isArrowFunction reports whether the current "(" starts the parameters of an
+// arrow function, instead of a grouping.
+func (p *Parser) isArrowFunction() bool {
+	// matching: "(" (IDENTIFIER ("," IDENTIFIER)*)? ")" "=>"
+	i := p.curr + 1
+	for i < len(p.tokens) && p.tokens[i].TokenType != golox.TokenTypeRightParen {
+		if p.tokens[i].TokenType != golox.TokenTypeIdentifier {
+			return false
+		}
+		i++
+		if i < len(p.tokens) && p.tokens[i].TokenType == golox.TokenTypeComma {
+			i++
+		}
+	}
+	return i+1 < len(p.tokens) && p.tokens[i+1].TokenType == golox.TokenTypeArrow
+}
+
+func (p *Parser) expressionArrowFunction() (*golox.ExpressionFunction, error) {
+	// matching: "(" (PARAMETER ("," PARAMETER))? ")" "=>" EXPRESSION
+	leftParen := p.skipToken()
+	result := &golox.StatementFun{
+		FunToken:   golox.Token{},
+		Identifier: anonymousIdentifier(leftParen),
+		Parameters: nil,
+		Body:       nil,
+	}
+
+	if params, err := p.functionParameters(result.Identifier); err != nil {
 		return nil, err
 	} else {
-		result.Body = stmt.Statements
+		result.Parameters = params
 	}
 
-	return result, nil
+	arrow, ok := p.expectTokenType(golox.TokenTypeArrow)
+	if !ok {
+		return nil, p.newErrorUnexpectedToken(arrow, "expect '=>' after function parameters")
+	}
+
+	// the body is an implicitly returned expression
+	if expr, err := p.parseExpression(); err != nil {
+		return nil, err
+	} else {
+		result.Body = []golox.Statement{
+			&golox.StatementReturn{ReturnToken: arrow, Expression: expr},
+		}
+	}
+
+	return &golox.ExpressionFunction{Declaration: result}, nil
 }
 
 func (p *Parser) statementReturn() (*golox.StatementReturn, error) {
@@ -478,7 +587,7 @@ func (p *Parser) statementReturn() (*golox.StatementReturn, error) {
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeReturn); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect 'return' keyword")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'return' keyword")
 	} else {
 		result.ReturnToken = tkn
 	}
@@ -492,7 +601,245 @@ func (p *Parser) statementReturn() (*golox.StatementReturn, error) {
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeSemicolon); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect ';' after return value")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect ';' after return value")
+	}
+
+	return result, nil
+}
+
+func (p *Parser) statementBreak() (*golox.StatementBreak, error) {
+	// matching: "break" ";"
+	result := &golox.StatementBreak{
+		BreakToken: golox.Token{},
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeBreak); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'break' keyword")
+	} else {
+		result.BreakToken = tkn
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeSemicolon); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect ';' after 'break'")
+	}
+
+	return result, nil
+}
+
+func (p *Parser) statementContinue() (*golox.StatementContinue, error) {
+	// matching: "continue" ";"
+	result := &golox.StatementContinue{
+		ContinueToken: golox.Token{},
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeContinue); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'continue' keyword")
+	} else {
+		result.ContinueToken = tkn
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeSemicolon); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect ';' after 'continue'")
+	}
+
+	return result, nil
+}
+
+func (p *Parser) statementThrow() (*golox.StatementThrow, error) {
+	// matching: "throw" EXPRESSION ";"
+	result := &golox.StatementThrow{
+		ThrowToken: golox.Token{},
+		Expression: nil,
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeThrow); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'throw' keyword")
+	} else {
+		result.ThrowToken = tkn
+	}
+
+	if expr, err := p.parseExpression(); err != nil {
+		return nil, err
+	} else {
+		result.Expression = expr
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeSemicolon); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect ';' after thrown value")
+	}
+
+	return result, nil
+}
+
+func (p *Parser) statementTry() (*golox.StatementTry, error) {
+	// matching: "try" STATEMENT_BLOCK ("catch" "(" IDENTIFIER ")" STATEMENT_BLOCK)? ("finally" STATEMENT_BLOCK)?
+	result := &golox.StatementTry{
+		TryToken:        golox.Token{},
+		Body:            nil,
+		CatchIdentifier: golox.Token{},
+		Catch:           nil,
+		Finally:         nil,
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeTry); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'try' keyword")
+	} else {
+		result.TryToken = tkn
+	}
+
+	if stmt, err := p.statementBlock(); err != nil {
+		return nil, err
+	} else {
+		result.Body = stmt
+	}
+
+	if p.peekTokenType() == golox.TokenTypeCatch {
+		_ = p.skipToken()
+
+		if tkn, ok := p.expectTokenType(golox.TokenTypeLeftParen); !ok {
+			return nil, p.newErrorUnexpectedToken(tkn, "expect '(' after 'catch'")
+		} else if tkn, ok := p.expectTokenType(golox.TokenTypeIdentifier); !ok {
+			return nil, p.newErrorUnexpectedToken(tkn, "expect variable name after '('")
+		} else {
+			result.CatchIdentifier = tkn
+		}
+
+		if tkn, ok := p.expectTokenType(golox.TokenTypeRightParen); !ok {
+			return nil, p.newErrorUnexpectedToken(tkn, "expect ')' after catch variable")
+		}
+
+		if stmt, err := p.statementBlock(); err != nil {
+			return nil, err
+		} else {
+			result.Catch = stmt
+		}
+	}
+
+	if p.peekTokenType() == golox.TokenTypeFinally {
+		_ = p.skipToken()
+
+		if stmt, err := p.statementBlock(); err != nil {
+			return nil, err
+		} else {
+			result.Finally = stmt
+		}
+	}
+
+	if result.Catch == nil && result.Finally == nil {
+		tkn := p.tokens[p.curr]
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'catch' or 'finally' after try block")
+	}
+
+	return result, nil
+}
+
+func (p *Parser) statementImport() (*golox.StatementImport, error) {
+	// matching: "import" STRING "as" IDENTIFIER ";"
+	result := &golox.StatementImport{
+		ImportToken: golox.Token{},
+		Path:        golox.Token{},
+		Alias:       golox.Token{},
+		Names:       nil,
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeImport); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'import' keyword")
+	} else {
+		result.ImportToken = tkn
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeString); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect module path after 'import'")
+	} else {
+		result.Path = tkn
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeAs); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'as' after module path")
+	} else if tkn, ok := p.expectTokenType(golox.TokenTypeIdentifier); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect module name after 'as'")
+	} else {
+		result.Alias = tkn
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeSemicolon); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect ';' after import")
+	}
+
+	return result, nil
+}
+
+func (p *Parser) statementFromImport() (*golox.StatementImport, error) {
+	// matching: "from" STRING "import" IDENTIFIER ("," IDENTIFIER)* ";"
+	result := &golox.StatementImport{
+		ImportToken: golox.Token{},
+		Path:        golox.Token{},
+		Alias:       golox.Token{},
+		Names:       []golox.Token{},
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeFrom); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'from' keyword")
+	} else {
+		result.ImportToken = tkn
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeString); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect module path after 'from'")
+	} else {
+		result.Path = tkn
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeImport); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'import' after module path")
+	}
+
+	for {
+		if tkn, ok := p.expectTokenType(golox.TokenTypeIdentifier); !ok {
+			return nil, p.newErrorUnexpectedToken(tkn, "expect name to import")
+		} else {
+			result.Names = append(result.Names, tkn)
+		}
+		if p.peekTokenType() != golox.TokenTypeComma {
+			break
+		}
+		_ = p.skipToken()
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeSemicolon); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect ';' after import")
+	}
+
+	return result, nil
+}
+
+func (p *Parser) statementExport() (*golox.StatementExport, error) {
+	// matching: "export" (STATEMENT_VAR | STATEMENT_FUNCTION | STATEMENT_CLASS)
+	result := &golox.StatementExport{
+		ExportToken: golox.Token{},
+		Declaration: nil,
+	}
+
+	if tkn, ok := p.expectTokenType(golox.TokenTypeExport); !ok {
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'export' keyword")
+	} else {
+		result.ExportToken = tkn
+	}
+
+	var err error
+	switch p.peekTokenType() {
+	case golox.TokenTypeVar:
+		result.Declaration, err = p.statementVar()
+	case golox.TokenTypeFun:
+		result.Declaration, err = p.statementFun(FunctionTypeFunction)
+	case golox.TokenTypeClass:
+		result.Declaration, err = p.statementClass()
+	default:
+		tkn := p.tokens[p.curr]
+		return nil, p.newErrorUnexpectedToken(tkn, "expect declaration after 'export'")
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -508,13 +855,13 @@ func (p *Parser) statementClass() (*golox.StatementClass, error) {
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeClass); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect 'class' keyword")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'class' keyword")
 	} else {
 		result.ClassToken = tkn
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeIdentifier); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect class name")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect class name")
 	} else {
 		result.Identifier = tkn
 	}
@@ -522,7 +869,7 @@ func (p *Parser) statementClass() (*golox.StatementClass, error) {
 	if p.peekTokenType() == golox.TokenTypeLess {
 		_ = p.skipToken()
 		if tkn, ok := p.expectTokenType(golox.TokenTypeIdentifier); !ok {
-			return nil, golox.NewErrorf(tkn.Location, "expect superclass name after '<'")
+			return nil, p.newErrorUnexpectedToken(tkn, "expect superclass name after '<'")
 		} else {
 			result.Superclass = &golox.ExpressionVariable{
 				Identifier: tkn,
@@ -531,7 +878,7 @@ func (p *Parser) statementClass() (*golox.StatementClass, error) {
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeLeftBrace); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect '{' before class body")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect '{' before class body")
 	}
 
 	for p.peekTokenType() != golox.TokenTypeRightBrace {
@@ -543,7 +890,7 @@ func (p *Parser) statementClass() (*golox.StatementClass, error) {
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeRightBrace); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect '}' after class body")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect '}' after class body")
 	}
 
 	return result, nil
@@ -557,7 +904,7 @@ func (p *Parser) statementPrint() (*golox.StatementPrint, error) {
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypePrint); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect 'print' keyword")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect 'print' keyword")
 	} else {
 		result.PrintToken = tkn
 	}
@@ -569,7 +916,7 @@ func (p *Parser) statementPrint() (*golox.StatementPrint, error) {
 	}
 
 	if tkn, ok := p.expectTokenType(golox.TokenTypeSemicolon); !ok {
-		return nil, golox.NewErrorf(tkn.Location, "expect ';' after print")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect ';' after print")
 	}
 
 	return result, nil
@@ -581,7 +928,7 @@ func (p *Parser) parseExpression() (golox.Expression, error) {
 }
 
 func (p *Parser) expressionAssignment() (golox.Expression, error) {
-	// matching: (EXPRESSION_VARIABLE|EXPRESSION_GET) ("=" EXPRESSION)*
+	// matching: (EXPRESSION_VARIABLE|EXPRESSION_GET|EXPRESSION_INDEX) ("=" EXPRESSION)*
 	var lhs golox.Expression
 
 	if expr, err := p.expressionLogicOr(); err != nil {
@@ -617,11 +964,19 @@ func (p *Parser) expressionAssignment() (golox.Expression, error) {
 					Value:      rhs,
 				}, nil
 			}
+		case *golox.ExpressionIndex:
+			if rhs, err := p.expressionAssignment(); err != nil {
+				return nil, err
+			} else {
+				return &golox.ExpressionIndexSet{
+					Object:      lhs.Object,
+					LeftBracket: lhs.LeftBracket,
+					Index:       lhs.Index,
+					Value:       rhs,
+				}, nil
+			}
 		default:
-			return nil, golox.NewErrorf(
-				equalTkn.Location,
-				"invalid assignment lvalue",
-			)
+			return nil, p.newErrorInvalidAssignmentTarget(equalTkn)
 		}
 	}
 }
@@ -815,7 +1170,7 @@ func (p *Parser) expressionUnary() (golox.Expression, error) {
 }
 
 func (p *Parser) expressionCall() (golox.Expression, error) {
-	// matching: EXPRESSION ("." IDENTIFIER | ("(" (IDENTIFIER ("," IDENTIFIER)*)? ")"))*
+	// matching: EXPRESSION ("." IDENTIFIER | ("(" (IDENTIFIER ("," IDENTIFIER)*)? ")") | "[" EXPRESSION "]")*
 	var lhs golox.Expression
 
 	if expr, err := p.expressionPrimary(); err != nil {
@@ -831,7 +1186,7 @@ func (p *Parser) expressionCall() (golox.Expression, error) {
 			_ = p.skipToken()
 
 			if tkn, ok := p.expectTokenType(golox.TokenTypeIdentifier); !ok {
-				return nil, golox.NewErrorf(tkn.Location, "expect property name after '.'")
+				return nil, p.newErrorUnexpectedToken(tkn, "expect property name after '.'")
 			} else {
 				lhs = &golox.ExpressionGet{
 					Object:     lhs,
@@ -855,17 +1210,14 @@ func (p *Parser) expressionCall() (golox.Expression, error) {
 					} else {
 						tkn := p.skipToken()
 						if len(arguments) >= 255 {
-							return nil, golox.NewErrorf(
-								tkn.Location,
-								"function call cannot have more than 255 parameters",
-							)
+							return nil, p.newErrorTooManyArguments(tkn)
 						}
 					}
 				}
 			}
 
 			if tkn, ok := p.expectTokenType(golox.TokenTypeRightParen); !ok {
-				return nil, golox.NewErrorf(tkn.Location, "expect ')' after function arguments")
+				return nil, p.newErrorUnexpectedToken(tkn, "expect ')' after function arguments")
 			} else {
 				lhs = &golox.ExpressionCall{
 					Callee:     lhs,
@@ -873,6 +1225,20 @@ func (p *Parser) expressionCall() (golox.Expression, error) {
 					Arguments:  arguments,
 				}
 			}
+		case golox.TokenTypeLeftBracket:
+			leftBracket := p.skipToken()
+
+			if index, err := p.parseExpression(); err != nil {
+				return nil, err
+			} else if tkn, ok := p.expectTokenType(golox.TokenTypeRightBracket); !ok {
+				return nil, p.newErrorUnexpectedToken(tkn, "expect ']' after index")
+			} else {
+				lhs = &golox.ExpressionIndex{
+					Object:      lhs,
+					LeftBracket: leftBracket,
+					Index:       index,
+				}
+			}
 		default:
 			return lhs, nil
 		}
@@ -911,7 +1277,17 @@ func (p *Parser) expressionPrimary() (golox.Expression, error) {
 			Location:     tkn.Location,
 			LiteralValue: tkn.LiteralValue,
 		}, nil
+	case golox.TokenTypeFun:
+		if stmt, err := p.statementFun(FunctionTypeAnonymous); err != nil {
+			return nil, err
+		} else {
+			return &golox.ExpressionFunction{Declaration: stmt}, nil
+		}
 	case golox.TokenTypeLeftParen:
+		if p.isArrowFunction() {
+			return p.expressionArrowFunction()
+		}
+
 		result := &golox.ExpressionGrouping{
 			LeftParenToken: golox.Token{},
 			Expression:     nil,
@@ -924,11 +1300,73 @@ func (p *Parser) expressionPrimary() (golox.Expression, error) {
 			result.Expression = expr
 
 			if tkn, ok := p.expectTokenType(golox.TokenTypeRightParen); !ok {
-				return nil, golox.NewErrorf(tkn.Location, "expect closing ')'")
+				return nil, p.newErrorUnexpectedToken(tkn, "expect closing ')'")
 			}
 
 			return result, nil
 		}
+	case golox.TokenTypeLeftBracket:
+		// matching: "[" (EXPRESSION ("," EXPRESSION)*)? "]"
+		result := &golox.ExpressionList{
+			LeftBracket: p.skipToken(),
+			Elements:    []golox.Expression{},
+		}
+
+		if p.peekTokenType() != golox.TokenTypeRightBracket {
+			for {
+				if expr, err := p.parseExpression(); err != nil {
+					return nil, err
+				} else {
+					result.Elements = append(result.Elements, expr)
+				}
+
+				if p.peekTokenType() != golox.TokenTypeComma {
+					break
+				} else {
+					_ = p.skipToken()
+				}
+			}
+		}
+
+		if tkn, ok := p.expectTokenType(golox.TokenTypeRightBracket); !ok {
+			return nil, p.newErrorUnexpectedToken(tkn, "expect ']' after list elements")
+		}
+
+		return result, nil
+	case golox.TokenTypeLeftBrace:
+		// matching: "{" (EXPRESSION ":" EXPRESSION ("," EXPRESSION ":" EXPRESSION)*)? "}"
+		result := &golox.ExpressionMap{
+			LeftBrace: p.skipToken(),
+			Keys:      []golox.Expression{},
+			Values:    []golox.Expression{},
+		}
+
+		if p.peekTokenType() != golox.TokenTypeRightBrace {
+			for {
+				if key, err := p.parseExpression(); err != nil {
+					return nil, err
+				} else if tkn, ok := p.expectTokenType(golox.TokenTypeColon); !ok {
+					return nil, p.newErrorUnexpectedToken(tkn, "expect ':' after map key")
+				} else if val, err := p.parseExpression(); err != nil {
+					return nil, err
+				} else {
+					result.Keys = append(result.Keys, key)
+					result.Values = append(result.Values, val)
+				}
+
+				if p.peekTokenType() != golox.TokenTypeComma {
+					break
+				} else {
+					_ = p.skipToken()
+				}
+			}
+		}
+
+		if tkn, ok := p.expectTokenType(golox.TokenTypeRightBrace); !ok {
+			return nil, p.newErrorUnexpectedToken(tkn, "expect '}' after map entries")
+		}
+
+		return result, nil
 	case golox.TokenTypeIdentifier:
 		tkn := p.skipToken()
 		return &golox.ExpressionVariable{Identifier: tkn}, nil
@@ -939,15 +1377,16 @@ func (p *Parser) expressionPrimary() (golox.Expression, error) {
 		result := &golox.ExpressionSuper{
 			SuperToken: golox.Token{},
 			Method:     golox.Token{},
+			Binding:    golox.Binding{},
 		}
 		result.SuperToken = p.skipToken()
 
 		if tkn, ok := p.expectTokenType(golox.TokenTypeDot); !ok {
-			return nil, golox.NewErrorf(tkn.Location, "expect '.' after 'super'")
+			return nil, p.newErrorUnexpectedToken(tkn, "expect '.' after 'super'")
 		}
 
 		if tkn, ok := p.expectTokenType(golox.TokenTypeIdentifier); !ok {
-			return nil, golox.NewErrorf(tkn.Location, "expect superclass method name after 'super.'")
+			return nil, p.newErrorUnexpectedToken(tkn, "expect superclass method name after 'super.'")
 		} else {
 			result.Method = tkn
 		}
@@ -955,21 +1394,21 @@ func (p *Parser) expressionPrimary() (golox.Expression, error) {
 		return result, nil
 	default:
 		tkn := p.skipToken()
-		return nil, golox.NewErrorf(tkn.Location, "expect expression")
+		return nil, p.newErrorUnexpectedToken(tkn, "expect expression")
 	}
 }
 
 func (p *Parser) logParsedStatement(stmt golox.Statement) {
-	golox.Logf(golox.ModuleParser, "")
-	golox.Logf(golox.ModuleParser, "%s", stmt.GetLocation())
-	golox.Logf(golox.ModuleParser, "|")
+	p.logger.Logf(golox.ModuleParser, "")
+	p.logger.Logf(golox.ModuleParser, "%s", stmt.GetLocation())
+	p.logger.Logf(golox.ModuleParser, "|")
 	indentLevel := 0
 	for _, line := range strings.Split(stmt.String(), "\n") {
 		if strings.HasPrefix(line, "}") {
 			indentLevel--
 		}
 
-		golox.Logf(golox.ModuleParser, "|%s%s",
+		p.logger.Logf(golox.ModuleParser, "|%s%s",
 			strings.Repeat(golox.STMT_INDENT, indentLevel), line,
 		)
 
@@ -977,7 +1416,7 @@ func (p *Parser) logParsedStatement(stmt golox.Statement) {
 			indentLevel++
 		}
 	}
-	golox.Logf(golox.ModuleParser, "|")
+	p.logger.Logf(golox.ModuleParser, "|")
 }
 
 func (p *Parser) StatementsFromTokens(tokens []golox.Token) ([]golox.Statement, error) {
@@ -987,7 +1426,7 @@ func (p *Parser) StatementsFromTokens(tokens []golox.Token) ([]golox.Statement,
 
 	for {
 		if stmt, err := p.parseDeclaration(); err != nil {
-			p.errors = append(p.errors, err)
+			p.errors = append(p.errors, err.(*golox.Diagnostic))
 		} else if stmt == nil {
 			break
 		} else {
@@ -999,22 +1438,12 @@ func (p *Parser) StatementsFromTokens(tokens []golox.Token) ([]golox.Statement,
 	if len(p.errors) == 0 {
 		return p.stmts, nil
 	} else {
-		var builder strings.Builder
-
-		builder.WriteString("------\nsyntax errors:\n------\n")
-		for _, err := range p.errors {
-			builder.WriteString(err.Error())
-			builder.WriteByte('\n')
-		}
-		builder.WriteString("------\n")
-		builder.WriteString("total ")
-		builder.WriteString(strconv.Itoa(len(p.errors)))
-		builder.WriteString(" errors")
-
-		return nil, fmt.Errorf(builder.String())
+		return nil, p.errors
 	}
 }
 
-func NewParser() *Parser {
-	return &Parser{}
+func NewParser(logger *golox.Logger) *Parser {
+	return &Parser{
+		logger: logger,
+	}
 }
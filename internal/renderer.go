@@ -0,0 +1,246 @@
+package golox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ANSI_BOLD    = "\x1b[1m"
+	ANSI_FG_RED  = "\x1b[31m"
+	ANSI_FG_BLUE = "\x1b[34m"
+	ANSI_RESET   = "\x1b[0m"
+)
+
+// Renderer renders errors with the offending source lines, e.g.
+//
+//	error[E0002]: operands must be both numbers
+//	 --> main.lox:2:9
+//	  |
+//	2 | print 1 - "a";
+//	  |         ^
+//	  = stack trace:
+//	      in f, called at main.lox:5:1
+type Renderer struct {
+	srcPathToLines map[string][]string
+}
+
+// AddSource registers source, so that diagnostics in srcPath can be rendered
+// with source lines.
+func (r *Renderer) AddSource(srcPath string, source []rune) {
+	lines := strings.Split(string(source), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	r.srcPathToLines[srcPath] = lines
+}
+
+// RemoveSource drops the source registered at srcPath, whose diagnostics are
+// then rendered without source lines.
+func (r *Renderer) RemoveSource(srcPath string) {
+	delete(r.srcPathToLines, srcPath)
+}
+
+// Reset drops all the registered sources.
+func (r *Renderer) Reset() {
+	r.srcPathToLines = map[string][]string{}
+}
+
+// SrcPaths returns the paths of the registered sources, sorted.
+func (r *Renderer) SrcPaths() []string {
+	srcPaths := make([]string, 0, len(r.srcPathToLines))
+	for srcPath := range r.srcPathToLines {
+		srcPaths = append(srcPaths, srcPath)
+	}
+	sort.Strings(srcPaths)
+	return srcPaths
+}
+
+// Render writes err to w. Diagnostics are rendered with source lines, other
+// errors are written as is.
+func (r *Renderer) Render(w io.Writer, err error, isColored bool) {
+	var diags Diagnostics
+	var diag *Diagnostic
+	switch {
+	case errors.As(err, &diags):
+	case errors.As(err, &diag):
+		diags = Diagnostics{diag}
+	default:
+		fmt.Fprintln(w, err)
+		return
+	}
+
+	for i, diag := range diags {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		r.renderDiagnostic(w, diag, isColored)
+	}
+}
+
+// span is a marked source span on a single line.
+type span struct {
+	line      int
+	startCol  int
+	endCol    int // exclusive
+	message   string
+	isPrimary bool
+}
+
+func (r *Renderer) renderDiagnostic(w io.Writer, diag *Diagnostic, isColored bool) {
+	style := func(ansi string, s string) string {
+		if isColored {
+			return ansi + s + ANSI_RESET
+		}
+		return s
+	}
+
+	severityStyle := ANSI_BOLD + ANSI_FG_RED
+	if diag.Severity == SeverityWarning {
+		severityStyle = ANSI_BOLD + ANSI_FG_BLUE
+	}
+	fmt.Fprintf(w, "%s%s\n",
+		style(severityStyle, fmt.Sprintf("%s[%s]", diag.Severity, diag.Code)),
+		style(ANSI_BOLD, ": "+diag.Message),
+	)
+
+	lines, ok := r.srcPathToLines[diag.Start.SrcPath]
+	if !ok || diag.Start.Line == 0 {
+		fmt.Fprintf(w, " --> %s\n", diag.Start)
+		for _, label := range diag.Labels {
+			fmt.Fprintf(w, " --> %s: %s\n", label.Start, label.Message)
+		}
+		for _, note := range diag.Notes {
+			fmt.Fprintf(w, " = note: %s\n", note)
+		}
+		renderStackTrace(w, diag.StackTrace, 1, style)
+		return
+	}
+
+	spans := []span{newSpan(diag.Start, diag.End, "", true, lines)}
+	for _, label := range diag.Labels {
+		if label.Start.SrcPath == diag.Start.SrcPath {
+			spans = append(spans, newSpan(label.Start, label.End, label.Message, false, lines))
+		}
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].line < spans[j].line
+	})
+
+	gutterWidth := len(strconv.Itoa(spans[len(spans)-1].line))
+	gutter := func(s string) string {
+		return style(ANSI_BOLD+ANSI_FG_BLUE, fmt.Sprintf("%*s |", gutterWidth, s))
+	}
+
+	fmt.Fprintf(w, "%*s%s %s\n", gutterWidth, "", style(ANSI_BOLD+ANSI_FG_BLUE, "-->"), diag.Start)
+	fmt.Fprintln(w, gutter(""))
+	for i, sp := range spans {
+		if i > 0 && sp.line > spans[i-1].line+1 {
+			fmt.Fprintln(w, style(ANSI_BOLD+ANSI_FG_BLUE, strings.Repeat(".", gutterWidth+2)))
+		}
+		if i == 0 || sp.line != spans[i-1].line {
+			line := ""
+			if sp.line <= len(lines) {
+				line = lines[sp.line-1]
+			}
+			fmt.Fprintf(w, "%s %s\n", gutter(strconv.Itoa(sp.line)), line)
+		}
+
+		marker, markerStyle := "-", ANSI_BOLD+ANSI_FG_BLUE
+		if sp.isPrimary {
+			marker, markerStyle = "^", ANSI_BOLD+ANSI_FG_RED
+		}
+		markers := strings.Repeat(marker, sp.endCol-sp.startCol)
+		if sp.message != "" {
+			markers += " " + sp.message
+		}
+		fmt.Fprintf(w, "%s %s%s\n",
+			gutter(""), indentation(lines, sp), style(markerStyle, markers),
+		)
+	}
+	fmt.Fprintln(w, gutter(""))
+
+	for _, note := range diag.Notes {
+		fmt.Fprintf(w, "%*s %s %s\n", gutterWidth, "", style(ANSI_BOLD, "= note:"), note)
+	}
+	renderStackTrace(w, diag.StackTrace, gutterWidth+1, style)
+}
+
+// renderStackTrace writes one line per frame, the most recent call first.
+func renderStackTrace(w io.Writer, trace []StackFrame, indent int, style func(string, string) string) {
+	if len(trace) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%*s%s\n", indent, "", style(ANSI_BOLD, "= stack trace:"))
+	for _, line := range stackTraceLines(trace) {
+		fmt.Fprintf(w, "%*s    %s\n", indent, "", line)
+	}
+}
+
+// newSpan clips the span from start to end to the line of start, and marks at
+// least one character.
+func newSpan(start Location, end Location, message string, isPrimary bool, lines []string) span {
+	endCol := end.Col
+	if end.Line == 0 {
+		endCol = start.Col + 1
+	} else if end.Line != start.Line {
+		endCol = 1
+		if start.Line <= len(lines) {
+			endCol = len([]rune(lines[start.Line-1])) + 1
+		}
+	}
+	if endCol <= start.Col {
+		endCol = start.Col + 1
+	}
+
+	return span{
+		line:      start.Line,
+		startCol:  start.Col,
+		endCol:    endCol,
+		message:   message,
+		isPrimary: isPrimary,
+	}
+}
+
+// indentation returns the whitespaces before the span, keeping tabs in the
+// source line so that markers are aligned.
+func indentation(lines []string, sp span) string {
+	var line []rune
+	if sp.line <= len(lines) {
+		line = []rune(lines[sp.line-1])
+	}
+
+	var b strings.Builder
+	for i := 0; i < sp.startCol-1; i++ {
+		if i < len(line) && line[i] == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return b.String()
+}
+
+// IsColorSupported reports whether f is a terminal and colors are not disabled
+// by the NO_COLOR environment variable.
+func IsColorSupported(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if fi, err := f.Stat(); err != nil {
+		return false
+	} else {
+		return fi.Mode()&os.ModeCharDevice != 0
+	}
+}
+
+func NewRenderer() *Renderer {
+	return &Renderer{
+		srcPathToLines: map[string][]string{},
+	}
+}
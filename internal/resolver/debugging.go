@@ -1,9 +1,7 @@
 package resolver
 
 import (
-	"fmt"
 	golox "golox/internal"
-	"log"
 )
 
 const (
@@ -14,7 +12,7 @@ func (r *Resolver) logDeclaredVariableInCurrScope(
 	identifier golox.Token,
 ) {
 	if r.isDebug {
-		log.Printf("%s: %s: declared variable '%s' in scope level %d",
+		r.logger.Printf("%s: %s: declared variable '%s' in scope level %d",
 			log_prefix, identifier.Location, identifier.Lexeme, len(r.scopes),
 		)
 	}
@@ -24,7 +22,7 @@ func (r *Resolver) logDefinedVariableInCurrScope(
 	identifier golox.Token,
 ) {
 	if r.isDebug {
-		log.Printf("%s: %s: defined variable '%s' in scope level %d",
+		r.logger.Printf("%s: %s: defined variable '%s' in scope level %d",
 			log_prefix, identifier.Location, identifier.Lexeme, len(r.scopes),
 		)
 	}
@@ -34,7 +32,7 @@ func (r *Resolver) logDefinedThisInCurrScope(
 	classIdentifier golox.Token,
 ) {
 	if r.isDebug {
-		log.Printf("%s: %s: defined 'this' for class '%s' in scope level %d",
+		r.logger.Printf("%s: %s: defined 'this' for class '%s' in scope level %d",
 			log_prefix, classIdentifier.Location, classIdentifier.Lexeme, len(r.scopes),
 		)
 	}
@@ -44,7 +42,7 @@ func (r *Resolver) logDefinedSuperInCurrScope(
 	classIdentifier golox.Token,
 ) {
 	if r.isDebug {
-		log.Printf("%s: %s: defined 'super' for class '%s' in scope level %d",
+		r.logger.Printf("%s: %s: defined 'super' for class '%s' in scope level %d",
 			log_prefix, classIdentifier.Location, classIdentifier.Lexeme, len(r.scopes),
 		)
 	}
@@ -52,12 +50,11 @@ func (r *Resolver) logDefinedSuperInCurrScope(
 
 func (r *Resolver) logResolvedVariable(
 	identifier golox.Token,
-	expr golox.Expression,
-	dist int,
+	binding golox.Binding,
 ) {
 	if r.isDebug {
-		log.Printf("%s: %s: resolved '%s' with %s -> %d",
-			log_prefix, identifier.Location, identifier.Lexeme, expr, dist,
+		r.logger.Printf("%s: %s: resolved '%s' -> depth %d, slot %d",
+			log_prefix, identifier.Location, identifier.Lexeme, binding.Depth, binding.Slot,
 		)
 	}
 }
@@ -65,56 +62,70 @@ func (r *Resolver) logResolvedVariable(
 func (r *Resolver) newErrorVariableIsAlreadyDefined(
 	identifier golox.Token,
 ) error {
-	return fmt.Errorf("%s: variable '%s' is already defined in current scope",
-		identifier.Location, identifier.Lexeme,
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseResolver, golox.ErrorCodeVariableAlreadyDefined,
+		identifier,
+		"variable '%s' is already defined in current scope", identifier.Lexeme,
 	)
 }
 
 func (r *Resolver) newErrorVariableInItsOwnInitializer(
 	identifier golox.Token,
 ) error {
-	return fmt.Errorf("%s: cannot read variable '%s' in its own initializer",
-		identifier.Location, identifier.Lexeme,
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseResolver, golox.ErrorCodeVariableInOwnInitializer,
+		identifier,
+		"cannot read variable '%s' in its own initializer", identifier.Lexeme,
 	)
 }
 
 func (r *Resolver) newErrorTopLevelReturn(
 	returnToken golox.Token,
 ) error {
-	return fmt.Errorf("%s: invalid top-level 'return'",
-		returnToken.Location,
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseResolver, golox.ErrorCodeTopLevelReturn,
+		returnToken,
+		"invalid top-level 'return'",
 	)
 }
 
 func (r *Resolver) newErrorReturnWithValueInInitializer(
 	returnToken golox.Token,
 ) error {
-	return fmt.Errorf("%s: invalid return statement with value in an initializer",
-		returnToken.Location,
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseResolver, golox.ErrorCodeReturnValueInInitializer,
+		returnToken,
+		"invalid return statement with value in an initializer",
 	)
 }
 
 func (r *Resolver) newErrorTopLevelThis(
 	thisToken golox.Token,
 ) error {
-	return fmt.Errorf("%s: invalid top-level 'this'",
-		thisToken.Location,
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseResolver, golox.ErrorCodeTopLevelThis,
+		thisToken,
+		"invalid top-level 'this'",
 	)
 }
 
 func (r *Resolver) newErrorSuperOutsideClass(
 	superToken golox.Token,
 ) error {
-	return fmt.Errorf("%s: invalid 'super' outside a class",
-		superToken.Location,
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseResolver, golox.ErrorCodeSuperOutsideClass,
+		superToken,
+		"invalid 'super' outside a class",
 	)
 }
 
 func (r *Resolver) newErrorSuperWithoutSuperclass(
 	superToken golox.Token,
 ) error {
-	return fmt.Errorf("%s: invalid 'super' in a class with no superclass",
-		superToken.Location,
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseResolver, golox.ErrorCodeSuperWithoutSuperclass,
+		superToken,
+		"invalid 'super' in a class with no superclass",
 	)
 }
 
@@ -122,15 +133,49 @@ func (r *Resolver) newErrorClassInheritFromItself(
 	classIdentifier golox.Token,
 	superclassIdentifier golox.Token,
 ) error {
-	return fmt.Errorf("%s: class '%s' inheriting from itself",
-		superclassIdentifier.Location, classIdentifier.Lexeme,
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseResolver, golox.ErrorCodeClassInheritsFromItself,
+		superclassIdentifier,
+		"class '%s' inheriting from itself", classIdentifier.Lexeme,
+	)
+}
+
+func (r *Resolver) newErrorBreakOutsideLoop(
+	breakToken golox.Token,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseResolver, golox.ErrorCodeBreakOutsideLoop,
+		breakToken,
+		"invalid 'break' outside a loop",
+	)
+}
+
+func (r *Resolver) newErrorContinueOutsideLoop(
+	continueToken golox.Token,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseResolver, golox.ErrorCodeContinueOutsideLoop,
+		continueToken,
+		"invalid 'continue' outside a loop",
+	)
+}
+
+func (r *Resolver) newErrorExportOutsideTopLevel(
+	exportToken golox.Token,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseResolver, golox.ErrorCodeExportOutsideTopLevel,
+		exportToken,
+		"invalid 'export' outside the top level",
 	)
 }
 
 func (r *Resolver) newErrorMissingImplementation(
 	node any,
 ) error {
-	return fmt.Errorf("missing implementation for type %T",
-		node,
+	return golox.NewDiagnostic(
+		golox.PhaseResolver, golox.ErrorCodeMissingImplementation,
+		golox.Location{}, golox.Location{},
+		"missing implementation for type %T", node,
 	)
 }
@@ -1,24 +1,32 @@
 package resolver
 
-import golox "golox/internal"
+import (
+	golox "golox/internal"
+	"log"
+)
 
 type Resolver struct {
 	// configs:
 	isDebug bool
+	logger  *log.Logger
 
 	// inputs:
 	// stmts []golox.Statement
 
-	// outputs:
-	resolvedLocalVars map[golox.Expression]int
-
 	// states:
-	scopes           []map[string]bool
+	scopes           []map[string]variable
 	currFunctionType FunctionType
 	currClassType    ClassType
+	loopDepth        int // of the enclosing loops in the current function
+}
+
+// variable is a local variable declared in a scope.
+type variable struct {
+	slot      int // index in the scope, in declaration order
+	isDefined bool
 }
 
-func (r *Resolver) currScope() (map[string]bool, bool) {
+func (r *Resolver) currScope() (map[string]variable, bool) {
 	if len(r.scopes) > 0 {
 		return r.scopes[len(r.scopes)-1], true
 	} else {
@@ -27,7 +35,7 @@ func (r *Resolver) currScope() (map[string]bool, bool) {
 }
 
 func (r *Resolver) beginScope() {
-	r.scopes = append(r.scopes, map[string]bool{})
+	r.scopes = append(r.scopes, map[string]variable{})
 }
 
 func (r *Resolver) endScope() {
@@ -40,7 +48,7 @@ func (r *Resolver) declareVarInCurrScope(identifier golox.Token) error {
 	} else if _, ok := currScope[identifier.Lexeme]; ok {
 		return r.newErrorVariableIsAlreadyDefined(identifier)
 	} else {
-		currScope[identifier.Lexeme] = false
+		currScope[identifier.Lexeme] = variable{slot: len(currScope), isDefined: false}
 		r.logDeclaredVariableInCurrScope(identifier)
 		return nil
 	}
@@ -48,28 +56,30 @@ func (r *Resolver) declareVarInCurrScope(identifier golox.Token) error {
 
 func (r *Resolver) defineVarInCurrScope(identifier golox.Token) {
 	if currScope, ok := r.currScope(); ok {
-		currScope[identifier.Lexeme] = true
+		v := currScope[identifier.Lexeme]
+		v.isDefined = true
+		currScope[identifier.Lexeme] = v
 		r.logDefinedVariableInCurrScope(identifier)
 	}
 }
 
 func (r *Resolver) defineThisInCurrScope(classIdentifier golox.Token) {
 	if currScope, ok := r.currScope(); ok {
-		currScope["this"] = true
+		currScope["this"] = variable{slot: len(currScope), isDefined: true}
 		r.logDefinedThisInCurrScope(classIdentifier)
 	}
 }
 
 func (r *Resolver) defineSuperInCurrScope(classIdentifier golox.Token) {
 	if currScope, ok := r.currScope(); ok {
-		currScope["super"] = true
+		currScope["super"] = variable{slot: len(currScope), isDefined: true}
 		r.logDefinedSuperInCurrScope(classIdentifier)
 	}
 }
 
 func (r *Resolver) isVarDeclaredInScope(
 	identifier golox.Token,
-	scope map[string]bool,
+	scope map[string]variable,
 ) bool {
 	_, ok := scope[identifier.Lexeme]
 	return ok
@@ -77,20 +87,25 @@ func (r *Resolver) isVarDeclaredInScope(
 
 func (r *Resolver) isVarDefinedInScope(
 	identifier golox.Token,
-	scope map[string]bool,
+	scope map[string]variable,
 ) bool {
-	return scope[identifier.Lexeme]
+	return scope[identifier.Lexeme].isDefined
 }
 
+// resolveVariable sets binding to the innermost declaration of identifier. The
+// binding is left as global if there is none.
 func (r *Resolver) resolveVariable(
 	identifier golox.Token,
-	expr golox.Expression,
+	binding *golox.Binding,
 ) {
 	for i := len(r.scopes) - 1; i >= 0; i-- {
-		if r.isVarDeclaredInScope(identifier, r.scopes[i]) {
-			dist := len(r.scopes) - 1 - i
-			r.resolvedLocalVars[expr] = dist
-			r.logResolvedVariable(identifier, expr, dist)
+		if v, ok := r.scopes[i][identifier.Lexeme]; ok {
+			*binding = golox.Binding{
+				IsLocal: true,
+				Depth:   len(r.scopes) - 1 - i,
+				Slot:    v.slot,
+			}
+			r.logResolvedVariable(identifier, *binding)
 			return
 		}
 	}
@@ -102,6 +117,8 @@ func (r *Resolver) resolveFunction(
 ) error {
 	lastFunctionType := r.currFunctionType
 	r.currFunctionType = functionType
+	lastLoopDepth := r.loopDepth
+	r.loopDepth = 0
 
 	r.beginScope()
 	for _, param := range stmt.Parameters {
@@ -117,6 +134,7 @@ func (r *Resolver) resolveFunction(
 	}
 	r.endScope()
 	r.currFunctionType = lastFunctionType
+	r.loopDepth = lastLoopDepth
 	return nil
 }
 
@@ -134,7 +152,7 @@ func (r *Resolver) resolveExpression(expr golox.Expression) error {
 			!r.isVarDefinedInScope(expr.Identifier, currScope) {
 			return r.newErrorVariableInItsOwnInitializer(expr.Identifier)
 		} else {
-			r.resolveVariable(expr.Identifier, expr)
+			r.resolveVariable(expr.Identifier, &expr.Binding)
 			return nil
 		}
 	case *golox.ExpressionCall:
@@ -160,7 +178,7 @@ func (r *Resolver) resolveExpression(expr golox.Expression) error {
 		case ClassTypeNone:
 			return r.newErrorTopLevelThis(expr.ThisToken)
 		default:
-			r.resolveVariable(expr.ThisToken, expr)
+			r.resolveVariable(expr.ThisToken, &expr.Binding)
 			return nil
 		}
 	case *golox.ExpressionSuper:
@@ -168,7 +186,7 @@ func (r *Resolver) resolveExpression(expr golox.Expression) error {
 		case ClassTypeNone:
 			return r.newErrorSuperOutsideClass(expr.SuperToken)
 		case ClassTypeSubclass:
-			r.resolveVariable(expr.SuperToken, expr)
+			r.resolveVariable(expr.SuperToken, &expr.Binding)
 			return nil
 		default:
 			return r.newErrorSuperWithoutSuperclass(expr.SuperToken)
@@ -193,8 +211,42 @@ func (r *Resolver) resolveExpression(expr golox.Expression) error {
 		if err := r.resolveExpression(expr.Value); err != nil {
 			return err
 		}
-		r.resolveVariable(expr.Identifier, expr)
+		r.resolveVariable(expr.Identifier, &expr.Binding)
 		return nil
+	case *golox.ExpressionFunction:
+		return r.resolveFunction(FunctionTypeFunction, expr.Declaration)
+	case *golox.ExpressionList:
+		for _, element := range expr.Elements {
+			if err := r.resolveExpression(element); err != nil {
+				return err
+			}
+		}
+	case *golox.ExpressionMap:
+		for i, key := range expr.Keys {
+			if err := r.resolveExpression(key); err != nil {
+				return err
+			}
+			if err := r.resolveExpression(expr.Values[i]); err != nil {
+				return err
+			}
+		}
+	case *golox.ExpressionIndex:
+		if err := r.resolveExpression(expr.Object); err != nil {
+			return err
+		}
+		if err := r.resolveExpression(expr.Index); err != nil {
+			return err
+		}
+	case *golox.ExpressionIndexSet:
+		if err := r.resolveExpression(expr.Object); err != nil {
+			return err
+		}
+		if err := r.resolveExpression(expr.Index); err != nil {
+			return err
+		}
+		if err := r.resolveExpression(expr.Value); err != nil {
+			return err
+		}
 	default:
 		return r.newErrorMissingImplementation(expr)
 	}
@@ -309,38 +361,93 @@ func (r *Resolver) resolveStatement(stmt golox.Statement) error {
 		if err := r.resolveExpression(stmt.Condition); err != nil {
 			return err
 		}
+		r.loopDepth++
+		err := r.resolveStatement(stmt.Body)
+		r.loopDepth--
+		if err != nil {
+			return err
+		}
+		if err := r.resolveExpression(stmt.Increment); err != nil {
+			return err
+		}
+	case *golox.StatementThrow:
+		return r.resolveExpression(stmt.Expression)
+	case *golox.StatementTry:
 		if err := r.resolveStatement(stmt.Body); err != nil {
 			return err
 		}
+		if stmt.Catch != nil {
+			// the caught value is in a scope around the catch block
+			r.beginScope()
+			if err := r.declareVarInCurrScope(stmt.CatchIdentifier); err != nil {
+				return err
+			}
+			r.defineVarInCurrScope(stmt.CatchIdentifier)
+			err := r.resolveStatement(stmt.Catch)
+			r.endScope()
+			if err != nil {
+				return err
+			}
+		}
+		if stmt.Finally != nil {
+			if err := r.resolveStatement(stmt.Finally); err != nil {
+				return err
+			}
+		}
+	case *golox.StatementImport:
+		if stmt.Names == nil {
+			if err := r.declareVarInCurrScope(stmt.Alias); err != nil {
+				return err
+			}
+			r.defineVarInCurrScope(stmt.Alias)
+		}
+		for _, name := range stmt.Names {
+			if err := r.declareVarInCurrScope(name); err != nil {
+				return err
+			}
+			r.defineVarInCurrScope(name)
+		}
+	case *golox.StatementExport:
+		if len(r.scopes) > 0 {
+			return r.newErrorExportOutsideTopLevel(stmt.ExportToken)
+		}
+		return r.resolveStatement(stmt.Declaration)
+	case *golox.StatementBreak:
+		if r.loopDepth == 0 {
+			return r.newErrorBreakOutsideLoop(stmt.BreakToken)
+		}
+	case *golox.StatementContinue:
+		if r.loopDepth == 0 {
+			return r.newErrorContinueOutsideLoop(stmt.ContinueToken)
+		}
 	default:
 		return r.newErrorMissingImplementation(stmt)
 	}
 	return nil
 }
 
+// ResolveStatements sets the bindings of the variables used in stmts.
 func (r *Resolver) ResolveStatements(
 	stmts []golox.Statement,
-) (
-	map[golox.Expression]int,
-	error,
-) {
-	r.resolvedLocalVars = map[golox.Expression]int{}
-	r.scopes = []map[string]bool{}
+) error {
+	r.scopes = []map[string]variable{}
 	r.currFunctionType = FunctionTypeNone
 	r.currClassType = ClassTypeNone
+	r.loopDepth = 0
 
 	for _, stmt := range stmts {
 		if err := r.resolveStatement(stmt); err != nil {
-			return nil, err
+			return err
 		}
 	}
-	return r.resolvedLocalVars, nil
+	return nil
 }
 
 func NewResolver(
-	isDebug bool,
+	config golox.Config,
 ) *Resolver {
 	return &Resolver{
-		isDebug: isDebug,
+		isDebug: config.IsDebug,
+		logger:  log.New(config.StderrWriter(), "", 0),
 	}
 }
@@ -0,0 +1,137 @@
+package runner
+
+import (
+	golox "golox/internal"
+	"golox/internal/interpreter"
+	"golox/internal/vm"
+	"sort"
+	"strings"
+)
+
+// Complete returns the names completing the identifier that ends line, and
+// the index in line where that identifier starts. After a '.', the names are
+// the properties of the value of the identifiers before it, e.g. `a.b.`, in
+// the current session. Otherwise, they are the keywords and the globals.
+func (r *Runner) Complete(line []rune) ([]string, int) {
+	start := identifierStart(line, len(line))
+	prefix := string(line[start:])
+
+	var names []string
+	if start > 0 && line[start-1] == '.' {
+		if val, ok := r.valueOfPath(line[:start-1]); ok {
+			names = propertyNames(val)
+		}
+	} else {
+		for keyword := range golox.Keywords {
+			names = append(names, keyword)
+		}
+		for name := range r.Interpreter().Globals() {
+			names = append(names, name)
+		}
+	}
+
+	candidates := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) && !seen[name] {
+			seen[name] = true
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Strings(candidates)
+	return candidates, start
+}
+
+// valueOfPath returns the value of the identifiers separated by '.' that end
+// line, e.g. `a.b`, where a is a global. Nothing is evaluated but globals and
+// properties, so that completing has no side effects.
+func (r *Runner) valueOfPath(line []rune) (any, bool) {
+	var path []string
+	end := len(line)
+	for {
+		start := identifierStart(line, end)
+		if start == end {
+			return nil, false
+		}
+		path = append([]string{string(line[start:end])}, path...)
+		if start == 0 || line[start-1] != '.' {
+			break
+		}
+		end = start - 1
+	}
+
+	val, ok := r.Interpreter().Globals()[path[0]]
+	for _, name := range path[1:] {
+		if !ok {
+			break
+		}
+		val, ok = property(val, name)
+	}
+	return val, ok
+}
+
+// property returns the field of an instance or the export of a module named
+// name. Methods are not bound, as they are only completed.
+func property(val any, name string) (any, bool) {
+	switch val := val.(type) {
+	case *interpreter.LoxInstance:
+		field, ok := val.Fields[name]
+		return field, ok
+	case *vm.Instance:
+		field, ok := val.Fields[name]
+		return field, ok
+	case *interpreter.LoxModule:
+		export, ok := val.Exports[name]
+		return export, ok
+	default:
+		return nil, false
+	}
+}
+
+// propertyNames returns the names of the fields and methods of an instance, or
+// of the exports of a module.
+func propertyNames(val any) []string {
+	var names []string
+	switch val := val.(type) {
+	case *interpreter.LoxInstance:
+		for name := range val.Fields {
+			names = append(names, name)
+		}
+		for c := val.Class; c != nil; c = c.Superclass {
+			for name := range c.Methods {
+				names = append(names, name)
+			}
+		}
+	case *vm.Instance:
+		for name := range val.Fields {
+			names = append(names, name)
+		}
+		for name := range val.Class.Methods {
+			names = append(names, name)
+		}
+	case *interpreter.LoxModule:
+		for name := range val.Exports {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// identifierStart returns the start of the identifier ending at end in line,
+// which is end if there is none.
+func identifierStart(line []rune, end int) int {
+	start := end
+	for start > 0 && isIdentifierRune(line[start-1]) {
+		start--
+	}
+	// identifiers do not start with a digit
+	for start < end && '0' <= line[start] && line[start] <= '9' {
+		start++
+	}
+	return start
+}
+
+// isIdentifierRune mirrors the characters of identifiers in the lexer.
+func isIdentifierRune(ch rune) bool {
+	return ('A' <= ch && ch <= 'Z') || ('a' <= ch && ch <= 'z') || ch == '_' || ('0' <= ch && ch <= '9')
+}
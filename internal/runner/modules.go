@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"bytes"
+	golox "golox/internal"
+	"golox/internal/interpreter"
+	"os"
+	"path/filepath"
+)
+
+// Import implements interpreter.Importer. A module is executed once per
+// session, in its own interpreter so that it has its own globals, and its
+// exports are the values of its exported globals at the end of the execution.
+// The execution counts in the budgets of run, the run importing the module.
+func (r *Runner) Import(run interpreter.Run, stmt *golox.StatementImport) (*interpreter.LoxModule, error) {
+	path, err := r.findModule(stmt)
+	if err != nil {
+		return nil, err
+	} else if module, ok := r.modules[path]; ok {
+		return module, nil
+	}
+	for i, importing := range r.importing {
+		if importing == path {
+			cycle := append(append([]string{}, r.importing[i:]...), path)
+			return nil, interpreter.NewErrorImportCycle(stmt.Path, cycle)
+		}
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		// e.g. the permissions of the file deny it, or it is removed since
+		return nil, interpreter.NewErrorModuleUnreadable(stmt.Path, err)
+	}
+
+	r.importing = append(r.importing, path)
+	defer func() { r.importing = r.importing[:len(r.importing)-1] }()
+
+	stmts, err := r.parse(bytes.Runes(source), path)
+	if err != nil {
+		return nil, err
+	}
+	itp := r.newInterpreter()
+	if err := itp.ImportStatements(run, stmts); err != nil {
+		return nil, err
+	}
+
+	module := &interpreter.LoxModule{Path: path, Exports: map[string]any{}}
+	for _, stmt := range stmts {
+		if stmt, ok := stmt.(*golox.StatementExport); ok {
+			name := stmt.Identifier().Lexeme
+			module.Exports[name], _ = itp.GetGlobal(name)
+		}
+	}
+	r.modules[path] = module
+	return module, nil
+}
+
+// findModule returns the absolute path of the module imported by stmt. A
+// relative path is searched in the directory of the importing file, then in
+// the module paths of the config.
+func (r *Runner) findModule(stmt *golox.StatementImport) (string, error) {
+	name := stmt.Path.LiteralValue.(string)
+	var dirs []string
+	if filepath.IsAbs(name) {
+		dirs = []string{""}
+	} else {
+		dirs = append([]string{filepath.Dir(stmt.Path.SrcPath)}, r.config.ModulePaths...)
+	}
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			continue
+		} else if abs, err := filepath.Abs(path); err != nil {
+			return "", err
+		} else {
+			return abs, nil
+		}
+	}
+
+	if filepath.IsAbs(name) {
+		return "", interpreter.NewErrorModuleNotFound(stmt.Path, nil)
+	}
+	return "", interpreter.NewErrorModuleNotFound(stmt.Path, dirs)
+}
@@ -0,0 +1,347 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	golox "golox/internal"
+	"golox/internal/interpreter"
+	"golox/internal/lexer"
+	"golox/internal/lineedit"
+	"golox/internal/parser"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	promptBanner       = "An interactive session of golox. Press Ctrl-d to end, or type :help for commands."
+	promptInput        = "> "
+	promptContinuation = "... "
+	promptSrcPath      = "REPL"
+)
+
+// commands are the meta-commands of an interactive session, typed with a
+// leading ':' instead of Lox code.
+var commands = []struct {
+	name string
+	args string
+	help string
+}{
+	{name: "load", args: "<file>", help: "runs a file in the session"},
+	{name: "env", args: "", help: "lists the global variables"},
+	{name: "ast", args: "<code>", help: "prints the statements parsed from code"},
+	{name: "tokens", args: "<code>", help: "prints the tokens of code"},
+	{name: "debug", args: "on|off", help: "enables or disables the debug logs"},
+	{name: "reset", args: "", help: "drops all the states of the session"},
+	{name: "time", args: "", help: "prints the running time of the next input"},
+	{name: "help", args: "", help: "lists the commands"},
+}
+
+// session is an interactive session of RunPrompt.
+type session struct {
+	runner     *Runner
+	stdout     io.Writer
+	lines      lineReader
+	errHandler func(error)
+	isTimed    bool // the next input is timed
+	inputs     int  // read so far, to number their source paths
+}
+
+// lineReader reads the lines of the inputs of a session, after a prompt.
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// scannerReader reads the lines of a stdin which is not a terminal, e.g. a
+// pipe, so they cannot be edited.
+type scannerReader struct {
+	stdout  io.Writer
+	scanner *bufio.Scanner
+}
+
+func (sr *scannerReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(sr.stdout, prompt)
+	if ok := sr.scanner.Scan(); !ok {
+		if err := sr.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return sr.scanner.Text(), nil
+}
+
+// RunPrompt runs an interactive session on the stdin of the config. An input
+// is read until its brackets and strings are closed, and the value of an input
+// ending with an expression statement is printed. An input starting with ':'
+// is a command, see :help.
+//
+// If stdin is a terminal, its lines are edited with a history, saved in the
+// config directory of the user, and tab completes keywords, globals and the
+// properties after a '.', see Complete.
+func (r *Runner) RunPrompt(errHandler func(error)) {
+	r.Reset()
+
+	s := &session{
+		runner:     r,
+		stdout:     r.config.StdoutWriter(),
+		lines:      nil,
+		errHandler: errHandler,
+		isTimed:    false,
+		inputs:     0,
+	}
+	if stdin := r.config.StdinReader(); lineedit.IsTerminal(stdin) {
+		s.lines = lineedit.NewEditor(stdin, s.stdout, s.loadHistory(), r.Complete)
+	} else {
+		s.lines = &scannerReader{
+			stdout:  s.stdout,
+			scanner: bufio.NewScanner(stdin),
+		}
+	}
+	fmt.Fprintln(s.stdout, promptBanner)
+	for {
+		source, ok := s.readInput()
+		if !ok {
+			// e.g. detected ctrl+d
+			fmt.Fprintln(s.stdout)
+			break
+		}
+
+		if line := strings.TrimSpace(string(source)); isCommand(line) {
+			s.runCommand(line)
+		} else {
+			s.runInput(source, s.nextSrcPath())
+		}
+	}
+}
+
+// readInput reads the lines of the next input, until it is complete or stdin
+// is closed.
+func (s *session) readInput() ([]rune, bool) {
+	var source []rune
+	prompt := promptInput
+	for {
+		line, err := s.lines.ReadLine(prompt)
+		if errors.Is(err, lineedit.ErrInterrupted) {
+			// ctrl+c discards the input
+			source = nil
+			prompt = promptInput
+			continue
+		} else if err != nil {
+			// an incomplete input is still run, to report its errors
+			return source, len(source) > 0
+		}
+
+		source = append(source, []rune(line)...)
+		source = append(source, '\n')
+		if prompt == promptInput && isCommand(strings.TrimSpace(line)) {
+			return source, true
+		} else if !isIncomplete(source) {
+			return source, true
+		}
+		prompt = promptContinuation
+	}
+}
+
+// loadHistory returns the history of the previous sessions, or an empty
+// history if it cannot be loaded.
+func (s *session) loadHistory() *lineedit.History {
+	path, err := lineedit.DefaultHistoryPath()
+	if err == nil {
+		var history *lineedit.History
+		if history, err = lineedit.LoadHistory(path); err == nil {
+			return history
+		}
+	}
+	s.errHandler(fmt.Errorf("the history is not saved: %w", err))
+	return lineedit.NewHistory()
+}
+
+// nextSrcPath returns the source path of the next input, e.g. "REPL#3". Each
+// input has its own path, so that an error raised by a function of an earlier
+// input is rendered with the line of that input.
+func (s *session) nextSrcPath() string {
+	s.inputs++
+	return fmt.Sprintf("%s#%d", promptSrcPath, s.inputs)
+}
+
+// runInput runs source at srcPath in the session, and prints its value if it
+// ends with an expression statement.
+func (s *session) runInput(source []rune, srcPath string) {
+	s.runner.srcPath = srcPath
+	start := time.Now()
+	val, isExpression, err := s.runner.runStatements(context.Background(), source)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		s.errHandler(err)
+	} else if isExpression {
+		fmt.Fprintln(s.stdout, interpreter.Stringify(val))
+	}
+	if s.isTimed {
+		s.isTimed = false
+		fmt.Fprintf(s.stdout, "took %s\n", elapsed)
+	}
+}
+
+// runCommand runs line, a command with its arguments.
+func (s *session) runCommand(line string) {
+	// the code of :ast and :tokens is only a source while its errors are
+	// handled
+	defer s.runner.renderer.RemoveSource(promptSrcPath)
+
+	name, args, _ := strings.Cut(strings.TrimPrefix(line, ":"), " ")
+	args = strings.TrimSpace(args)
+	switch name {
+	case "load":
+		s.load(args)
+	case "env":
+		s.printGlobals()
+	case "ast":
+		s.printStatements(args)
+	case "tokens":
+		s.printTokens(args)
+	case "debug":
+		s.setDebug(args)
+	case "reset":
+		s.runner.Reset()
+		fmt.Fprintln(s.stdout, "the session is reset")
+	case "time":
+		s.isTimed = true
+	case "help":
+		s.printHelp()
+	default:
+		s.errHandler(fmt.Errorf("unknown command ':%s', type :help for commands", name))
+	}
+}
+
+// load runs the file at path in the session, so that its globals are kept.
+func (s *session) load(path string) {
+	if path == "" {
+		s.errHandler(errors.New("usage: :load <file>"))
+		return
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		s.errHandler(err)
+		return
+	}
+
+	// the file is run at its own path, for the imports relative to it
+	srcPath := path
+	if abs, err := filepath.Abs(path); err == nil {
+		srcPath = abs
+	}
+	s.runInput(bytes.Runes(source), srcPath)
+}
+
+func (s *session) printGlobals() {
+	globals := s.runner.Interpreter().Globals()
+	names := make([]string, 0, len(globals))
+	for name := range globals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(s.stdout, "%s = %s\n", name, interpreter.Stringify(globals[name]))
+	}
+}
+
+// tokens returns the tokens of code, which is the source of promptSrcPath for
+// the errors of the command, without using up an input number.
+func (s *session) tokens(code string) ([]golox.Token, bool) {
+	source := []rune(code)
+	s.runner.renderer.AddSource(promptSrcPath, source)
+	tokens, err := lexer.
+		NewLexer(golox.NewLogger(false, io.Discard)).
+		TokensFromSource(source, promptSrcPath)
+	if err != nil {
+		s.errHandler(asDiagnostics(err))
+		return nil, false
+	}
+	return tokens, true
+}
+
+func (s *session) printTokens(code string) {
+	if tokens, ok := s.tokens(code); ok {
+		for _, tkn := range tokens {
+			fmt.Fprintf(s.stdout, "%d:%d\t%s\t%s\n", tkn.Line, tkn.Col, tkn.TokenType, tkn.Lexeme)
+		}
+	}
+}
+
+func (s *session) printStatements(code string) {
+	tokens, ok := s.tokens(code)
+	if !ok {
+		return
+	}
+	stmts, err := parser.
+		NewParser(golox.NewLogger(false, io.Discard)).
+		StatementsFromTokens(tokens)
+	if err != nil {
+		s.errHandler(asDiagnostics(err))
+		return
+	}
+	for _, stmt := range stmts {
+		if stmt != nil {
+			fmt.Fprintln(s.stdout, stmt.String())
+		}
+	}
+}
+
+func (s *session) setDebug(arg string) {
+	r := s.runner
+	switch arg {
+	case "on":
+		r.config.IsDebug = true
+	case "off":
+		r.config.IsDebug = false
+	default:
+		s.errHandler(errors.New("usage: :debug on|off"))
+		return
+	}
+	r.Interpreter().SetDebug(r.config.IsDebug)
+}
+
+func (s *session) printHelp() {
+	for _, cmd := range commands {
+		usage := ":" + cmd.name
+		if cmd.args != "" {
+			usage += " " + cmd.args
+		}
+		fmt.Fprintf(s.stdout, "%-16s %s\n", usage, cmd.help)
+	}
+}
+
+// isCommand reports whether the trimmed input line is a command.
+func isCommand(line string) bool {
+	return strings.HasPrefix(line, ":")
+}
+
+// isIncomplete reports whether source is the beginning of an input, as it has
+// an unterminated string or unclosed brackets.
+func isIncomplete(source []rune) bool {
+	tokens, err := lexer.
+		NewLexer(golox.NewLogger(false, io.Discard)).
+		TokensFromSource(source, "")
+	if err != nil {
+		var diag *golox.Diagnostic
+		return errors.As(err, &diag) && diag.Code == golox.ErrorCodeUnterminatedString
+	}
+
+	depth := 0
+	for _, tkn := range tokens {
+		switch tkn.TokenType {
+		case golox.TokenTypeLeftParen, golox.TokenTypeLeftBrace, golox.TokenTypeLeftBracket:
+			depth++
+		case golox.TokenTypeRightParen, golox.TokenTypeRightBrace, golox.TokenTypeRightBracket:
+			depth--
+		}
+	}
+	return depth > 0
+}
@@ -1,98 +1,238 @@
 package runner
 
 import (
-	"bufio"
 	"bytes"
-	"fmt"
+	"context"
+	"errors"
+	golox "golox/internal"
 	"golox/internal/interpreter"
 	"golox/internal/lexer"
 	"golox/internal/parser"
 	"golox/internal/resolver"
+	"golox/internal/vm"
+	"io"
 	"os"
 	"path/filepath"
 )
 
+// Interpreter executes resolved statements, see golox.Backend.
+type Interpreter interface {
+	InterpretStatements(ctx context.Context, stmts []golox.Statement) (any, error)
+	ImportStatements(run interpreter.Run, stmts []golox.Statement) error
+	GetGlobal(name string) (any, bool)
+	SetGlobal(name string, val any)
+	Globals() map[string]any
+	SetDebug(isDebug bool)
+	SetImporter(importer interpreter.Importer)
+}
+
+// maxRunSources is the number of the latest runs whose sources are kept to
+// render errors. The sources of older runs are dropped, so that a long session
+// does not keep all its inputs, and the errors raised by the functions they
+// define are rendered without source lines.
+const maxRunSources = 100
+
 type Runner struct {
 	// configs:
-	isDebug bool
+	config golox.Config
 
 	// states:
 	srcPath     string
-	interpreter *interpreter.Interpreter
+	interpreter Interpreter
+	renderer    *golox.Renderer
+	runSrcPaths []string                          // of the latest runs, the oldest first, see maxRunSources
+	modules     map[string]*interpreter.LoxModule // by absolute path, imported in the current session
+	importing   []string                          // paths of the files being executed, the outermost first
+}
+
+// asDiagnostics converts errors reported by a phase to golox.Diagnostics.
+func asDiagnostics(err error) error {
+	var diags golox.Diagnostics
+	var diag *golox.Diagnostic
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &diags):
+		return diags
+	case errors.As(err, &diag):
+		return golox.Diagnostics{diag}
+	default:
+		return err
+	}
 }
 
-func (r *Runner) run(source []rune) error {
+// run returns golox.Diagnostics for errors found in source.
+func (r *Runner) run(ctx context.Context, source []rune) (any, error) {
+	val, _, err := r.runStatements(ctx, source)
+	return val, err
+}
+
+// runStatements runs source, and reports whether it ends with an expression
+// statement, whose value is returned.
+func (r *Runner) runStatements(ctx context.Context, source []rune) (any, bool, error) {
+	r.importing = []string{r.srcPath}
+	r.keepRunSource(r.srcPath)
+	stmts, err := r.parse(source, r.srcPath)
+	if err != nil {
+		return nil, false, asDiagnostics(err)
+	}
+
+	// reuse interpreter to persist scopes in a run session
+	val, err := r.interpreter.
+		InterpretStatements(ctx, stmts)
+	if err != nil {
+		return nil, false, asDiagnostics(err)
+	}
+
+	isExpression := false
+	if len(stmts) > 0 {
+		_, isExpression = stmts[len(stmts)-1].(*golox.StatementExpression)
+	}
+	return val, isExpression, nil
+}
+
+// keepRunSource records srcPath as the path of the latest run, and drops the
+// source of the oldest run if more than maxRunSources are kept.
+func (r *Runner) keepRunSource(srcPath string) {
+	for i, p := range r.runSrcPaths {
+		if p == srcPath {
+			r.runSrcPaths = append(r.runSrcPaths[:i], r.runSrcPaths[i+1:]...)
+			break
+		}
+	}
+	r.runSrcPaths = append(r.runSrcPaths, srcPath)
+	if len(r.runSrcPaths) <= maxRunSources {
+		return
+	}
+
+	oldest := r.runSrcPaths[0]
+	r.runSrcPaths = r.runSrcPaths[1:]
+	if _, ok := r.modules[oldest]; !ok {
+		// the source of a module is kept with the module
+		r.renderer.RemoveSource(oldest)
+	}
+}
+
+// parse returns the resolved statements of source.
+func (r *Runner) parse(source []rune, srcPath string) ([]golox.Statement, error) {
+	r.renderer.AddSource(srcPath, source)
+	logger := golox.NewLogger(r.config.IsDebug, r.config.StderrWriter())
+
 	tokens, err := lexer.
-		NewLexer().
-		TokensFromSource(source, r.srcPath)
+		NewLexer(logger).
+		TokensFromSource(source, srcPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	stmts, err := parser.
-		NewParser().
+		NewParser(logger).
 		StatementsFromTokens(tokens)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	resolvedLocalVars, err := resolver.
-		NewResolver(r.isDebug).
-		ResolveStatements(stmts)
-	if err != nil {
-		return err
+	if err := resolver.
+		NewResolver(r.config).
+		ResolveStatements(stmts); err != nil {
+		return nil, err
 	}
+	return stmts, nil
+}
 
-	// reuse interpreter to persist scopes in a run session
-	if err := r.interpreter.
-		InterpretStatements(stmts, resolvedLocalVars); err != nil {
-		return err
+// RenderError writes err to w with the offending source lines.
+func (r *Runner) RenderError(w io.Writer, err error, isColored bool) {
+	r.renderer.Render(w, err, isColored)
+}
+
+// Renderer returns the renderer of the errors of the current session, with the
+// sources of its latest runs and of its modules.
+func (r *Runner) Renderer() *golox.Renderer {
+	return r.renderer
+}
+
+// Interpreter returns the interpreter of the current session, which runs on
+// the backend of the config.
+func (r *Runner) Interpreter() Interpreter {
+	if r.interpreter == nil {
+		r.Reset()
 	}
+	return r.interpreter
+}
 
-	return nil
+// Reset drops all states of the current session, including the imported
+// modules and the sources of the errors.
+func (r *Runner) Reset() {
+	r.interpreter = r.newInterpreter()
+	r.renderer.Reset()
+	r.runSrcPaths = nil
+	r.modules = map[string]*interpreter.LoxModule{}
+}
+
+// newInterpreter returns an interpreter on the backend of the config, which
+// imports modules with r.
+func (r *Runner) newInterpreter() Interpreter {
+	var itp Interpreter
+	if r.config.Backend == golox.BackendVM {
+		itp = vm.NewVM(r.config)
+	} else {
+		itp = interpreter.NewInterpreter(r.config)
+	}
+	itp.SetImporter(r)
+	return itp
 }
 
+// RunSource runs source in the current session, keeping globals defined by
+// previous runs. If the source ends with an expression statement, its value is
+// returned.
+func (r *Runner) RunSource(source []rune, srcPath string) (any, error) {
+	return r.RunSourceContext(context.Background(), source, srcPath)
+}
+
+// RunSourceContext is RunSource, aborted when ctx is done.
+func (r *Runner) RunSourceContext(ctx context.Context, source []rune, srcPath string) (any, error) {
+	r.srcPath = srcPath
+	if r.interpreter == nil {
+		r.Reset()
+	}
+
+	return r.run(ctx, source)
+}
+
+// RunFile runs the script at path in a new session.
 func (r *Runner) RunFile(path string) error {
+	return r.RunFileContext(context.Background(), path)
+}
+
+// RunFileContext is RunFile, aborted when ctx is done.
+func (r *Runner) RunFileContext(ctx context.Context, path string) error {
 	r.srcPath = path
-	if pwd, err := os.Getwd(); err == nil {
-		r.srcPath = filepath.Join(pwd, path)
+	if abs, err := filepath.Abs(path); err == nil {
+		r.srcPath = abs
 	}
-	r.interpreter = interpreter.NewInterpreter(r.isDebug)
+	r.Reset()
 
 	source, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
 
-	if err := r.run(bytes.Runes(source)); err != nil {
+	if _, err := r.run(ctx, bytes.Runes(source)); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (r *Runner) RunPrompt(errHandler func(error)) {
-	r.srcPath = "REPL"
-	r.interpreter = interpreter.NewInterpreter(r.isDebug)
-
-	reader := bufio.NewScanner(os.Stdin)
-	for {
-		fmt.Println("An interactive session of golox. Press Ctrl-d to end.")
-		fmt.Print("> ")
-		if ok := reader.Scan(); !ok {
-			// e.g. detected ctrl+d
-			break
-		} else if err := r.run(bytes.Runes(reader.Bytes())); err != nil {
-			errHandler(err)
-		}
-	}
-}
-
 func NewRunner(
-	isDebug bool,
+	config golox.Config,
 ) *Runner {
 	return &Runner{
-		isDebug:     isDebug,
+		config:      config,
 		interpreter: nil,
+		renderer:    golox.NewRenderer(),
+		runSrcPaths: nil,
+		modules:     nil,
+		importing:   nil,
 	}
 }
@@ -23,6 +23,12 @@ func (*StatementFun) implStatement()        {}
 func (*StatementReturn) implStatement()     {}
 func (*StatementClass) implStatement()      {}
 func (*StatementPrint) implStatement()      {}
+func (*StatementBreak) implStatement()      {}
+func (*StatementContinue) implStatement()   {}
+func (*StatementThrow) implStatement()      {}
+func (*StatementTry) implStatement()        {}
+func (*StatementImport) implStatement()     {}
+func (*StatementExport) implStatement()     {}
 
 type StatementBlock struct {
 	Location   // not requiring a Token, as the statement can be generated
@@ -125,6 +131,7 @@ type StatementWhile struct {
 	WhileToken Token
 	Condition  Expression
 	Body       Statement
+	Increment  Expression // of a for loop, evaluated after the body and on continue
 }
 
 func (stmt *StatementWhile) GetLocation() Location {
@@ -135,6 +142,10 @@ func (stmt *StatementWhile) String() string {
 	var b strings.Builder
 	b.WriteString("while ")
 	b.WriteString(stmt.Condition.String())
+	if stmt.Increment != nil {
+		b.WriteString("; ")
+		b.WriteString(stmt.Increment.String())
+	}
 
 	if stmtBlockBody, ok := stmt.Body.(*StatementBlock); ok {
 		b.WriteString(" ")
@@ -251,3 +262,133 @@ func (stmt *StatementPrint) String() string {
 	b.WriteString(";")
 	return b.String()
 }
+
+type StatementBreak struct {
+	BreakToken Token
+}
+
+func (stmt *StatementBreak) GetLocation() Location {
+	return stmt.BreakToken.Location
+}
+
+func (stmt *StatementBreak) String() string {
+	return "break;"
+}
+
+type StatementContinue struct {
+	ContinueToken Token
+}
+
+func (stmt *StatementContinue) GetLocation() Location {
+	return stmt.ContinueToken.Location
+}
+
+func (stmt *StatementContinue) String() string {
+	return "continue;"
+}
+
+type StatementThrow struct {
+	ThrowToken Token
+	Expression
+}
+
+func (stmt *StatementThrow) GetLocation() Location {
+	return stmt.ThrowToken.Location
+}
+
+func (stmt *StatementThrow) String() string {
+	var b strings.Builder
+	b.WriteString("throw ")
+	b.WriteString(stmt.Expression.String())
+	b.WriteString(";")
+	return b.String()
+}
+
+type StatementTry struct {
+	TryToken        Token
+	Body            *StatementBlock
+	CatchIdentifier Token           // zero if there is no catch clause
+	Catch           *StatementBlock // nil if there is no catch clause
+	Finally         *StatementBlock // nil if there is no finally clause
+}
+
+func (stmt *StatementTry) GetLocation() Location {
+	return stmt.TryToken.Location
+}
+
+func (stmt *StatementTry) String() string {
+	var b strings.Builder
+	b.WriteString("try ")
+	b.WriteString(stmt.Body.String())
+	if stmt.Catch != nil {
+		b.WriteString(" catch (")
+		b.WriteString(stmt.CatchIdentifier.Lexeme)
+		b.WriteString(") ")
+		b.WriteString(stmt.Catch.String())
+	}
+	if stmt.Finally != nil {
+		b.WriteString(" finally ")
+		b.WriteString(stmt.Finally.String())
+	}
+	return b.String()
+}
+
+type StatementImport struct {
+	ImportToken Token   // the 'import' token, or the 'from' token of a from import
+	Path        Token   // the string literal of the path of the module
+	Alias       Token   // of an import of the whole module, zero for a from import
+	Names       []Token // of a from import, nil for an import of the whole module
+}
+
+func (stmt *StatementImport) GetLocation() Location {
+	return stmt.ImportToken.Location
+}
+
+func (stmt *StatementImport) String() string {
+	var b strings.Builder
+	if stmt.Names == nil {
+		b.WriteString("import ")
+		b.WriteString(stmt.Path.Lexeme)
+		b.WriteString(" as ")
+		b.WriteString(stmt.Alias.Lexeme)
+	} else {
+		b.WriteString("from ")
+		b.WriteString(stmt.Path.Lexeme)
+		b.WriteString(" import ")
+		for i, name := range stmt.Names {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(name.Lexeme)
+		}
+	}
+	b.WriteString(";")
+	return b.String()
+}
+
+type StatementExport struct {
+	ExportToken Token
+	Declaration Statement // a *StatementVar, *StatementFun or *StatementClass
+}
+
+func (stmt *StatementExport) GetLocation() Location {
+	return stmt.ExportToken.Location
+}
+
+// Identifier returns the identifier of the exported declaration.
+func (stmt *StatementExport) Identifier() Token {
+	switch decl := stmt.Declaration.(type) {
+	case *StatementVar:
+		return decl.Identifier
+	case *StatementFun:
+		return decl.Identifier
+	case *StatementClass:
+		return decl.Identifier
+	default:
+		return Token{}
+	}
+}
+
+func (stmt *StatementExport) String() string {
+	return "export " + stmt.Declaration.String()
+}
@@ -18,9 +18,12 @@ const (
 	TokenTypeRightParen
 	TokenTypeLeftBrace
 	TokenTypeRightBrace
+	TokenTypeLeftBracket
+	TokenTypeRightBracket
 	TokenTypeComma
 	TokenTypeDot
 	TokenTypeSemicolon
+	TokenTypeColon
 	TokenTypePlus
 	TokenTypeMinus
 	TokenTypeStar
@@ -31,6 +34,7 @@ const (
 	TokenTypeBangEqual
 	TokenTypeEqual
 	TokenTypeEqualEqual
+	TokenTypeArrow
 	TokenTypeLess
 	TokenTypeLessEqual
 	TokenTypeGreater
@@ -57,8 +61,32 @@ const (
 	TokenTypeSuper
 	TokenTypeThis
 	TokenTypePrint
+	TokenTypeBreak
+	TokenTypeContinue
+	TokenTypeThrow
+	TokenTypeTry
+	TokenTypeCatch
+	TokenTypeFinally
+	TokenTypeImport
+	TokenTypeExport
+	TokenTypeFrom
+	TokenTypeAs
 
 	TokenTypeIdentifier
 
 	TokenTypeEOF
 )
+
+// End returns the location right after the last character of tkn.
+func (tkn Token) End() Location {
+	end := tkn.Location
+	for _, ch := range tkn.Lexeme {
+		if ch == '\n' {
+			end.Line++
+			end.Col = 1
+		} else {
+			end.Col++
+		}
+	}
+	return end
+}
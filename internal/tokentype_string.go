@@ -13,46 +13,60 @@ func _() {
 	_ = x[TokenTypeRightParen-2]
 	_ = x[TokenTypeLeftBrace-3]
 	_ = x[TokenTypeRightBrace-4]
-	_ = x[TokenTypeComma-5]
-	_ = x[TokenTypeDot-6]
-	_ = x[TokenTypeSemicolon-7]
-	_ = x[TokenTypePlus-8]
-	_ = x[TokenTypeMinus-9]
-	_ = x[TokenTypeStar-10]
-	_ = x[TokenTypeSlash-11]
-	_ = x[TokenTypeBang-12]
-	_ = x[TokenTypeBangEqual-13]
-	_ = x[TokenTypeEqual-14]
-	_ = x[TokenTypeEqualEqual-15]
-	_ = x[TokenTypeLess-16]
-	_ = x[TokenTypeLessEqual-17]
-	_ = x[TokenTypeGreater-18]
-	_ = x[TokenTypeGreaterEqual-19]
-	_ = x[TokenTypeString-20]
-	_ = x[TokenTypeNumber-21]
-	_ = x[TokenTypeVar-22]
-	_ = x[TokenTypeNil-23]
-	_ = x[TokenTypeTrue-24]
-	_ = x[TokenTypeFalse-25]
-	_ = x[TokenTypeAnd-26]
-	_ = x[TokenTypeOr-27]
-	_ = x[TokenTypeIf-28]
-	_ = x[TokenTypeElse-29]
-	_ = x[TokenTypeFor-30]
-	_ = x[TokenTypeWhile-31]
-	_ = x[TokenTypeFun-32]
-	_ = x[TokenTypeReturn-33]
-	_ = x[TokenTypeClass-34]
-	_ = x[TokenTypeSuper-35]
-	_ = x[TokenTypeThis-36]
-	_ = x[TokenTypePrint-37]
-	_ = x[TokenTypeIdentifier-38]
-	_ = x[TokenTypeEOF-39]
+	_ = x[TokenTypeLeftBracket-5]
+	_ = x[TokenTypeRightBracket-6]
+	_ = x[TokenTypeComma-7]
+	_ = x[TokenTypeDot-8]
+	_ = x[TokenTypeSemicolon-9]
+	_ = x[TokenTypeColon-10]
+	_ = x[TokenTypePlus-11]
+	_ = x[TokenTypeMinus-12]
+	_ = x[TokenTypeStar-13]
+	_ = x[TokenTypeSlash-14]
+	_ = x[TokenTypeBang-15]
+	_ = x[TokenTypeBangEqual-16]
+	_ = x[TokenTypeEqual-17]
+	_ = x[TokenTypeEqualEqual-18]
+	_ = x[TokenTypeArrow-19]
+	_ = x[TokenTypeLess-20]
+	_ = x[TokenTypeLessEqual-21]
+	_ = x[TokenTypeGreater-22]
+	_ = x[TokenTypeGreaterEqual-23]
+	_ = x[TokenTypeString-24]
+	_ = x[TokenTypeNumber-25]
+	_ = x[TokenTypeVar-26]
+	_ = x[TokenTypeNil-27]
+	_ = x[TokenTypeTrue-28]
+	_ = x[TokenTypeFalse-29]
+	_ = x[TokenTypeAnd-30]
+	_ = x[TokenTypeOr-31]
+	_ = x[TokenTypeIf-32]
+	_ = x[TokenTypeElse-33]
+	_ = x[TokenTypeFor-34]
+	_ = x[TokenTypeWhile-35]
+	_ = x[TokenTypeFun-36]
+	_ = x[TokenTypeReturn-37]
+	_ = x[TokenTypeClass-38]
+	_ = x[TokenTypeSuper-39]
+	_ = x[TokenTypeThis-40]
+	_ = x[TokenTypePrint-41]
+	_ = x[TokenTypeBreak-42]
+	_ = x[TokenTypeContinue-43]
+	_ = x[TokenTypeThrow-44]
+	_ = x[TokenTypeTry-45]
+	_ = x[TokenTypeCatch-46]
+	_ = x[TokenTypeFinally-47]
+	_ = x[TokenTypeImport-48]
+	_ = x[TokenTypeExport-49]
+	_ = x[TokenTypeFrom-50]
+	_ = x[TokenTypeAs-51]
+	_ = x[TokenTypeIdentifier-52]
+	_ = x[TokenTypeEOF-53]
 }
 
-const _TokenType_name = "TokenTypeUndefinedTokenTypeLeftParenTokenTypeRightParenTokenTypeLeftBraceTokenTypeRightBraceTokenTypeCommaTokenTypeDotTokenTypeSemicolonTokenTypePlusTokenTypeMinusTokenTypeStarTokenTypeSlashTokenTypeBangTokenTypeBangEqualTokenTypeEqualTokenTypeEqualEqualTokenTypeLessTokenTypeLessEqualTokenTypeGreaterTokenTypeGreaterEqualTokenTypeStringTokenTypeNumberTokenTypeVarTokenTypeNilTokenTypeTrueTokenTypeFalseTokenTypeAndTokenTypeOrTokenTypeIfTokenTypeElseTokenTypeForTokenTypeWhileTokenTypeFunTokenTypeReturnTokenTypeClassTokenTypeSuperTokenTypeThisTokenTypePrintTokenTypeIdentifierTokenTypeEOF"
+const _TokenType_name = "TokenTypeUndefinedTokenTypeLeftParenTokenTypeRightParenTokenTypeLeftBraceTokenTypeRightBraceTokenTypeLeftBracketTokenTypeRightBracketTokenTypeCommaTokenTypeDotTokenTypeSemicolonTokenTypeColonTokenTypePlusTokenTypeMinusTokenTypeStarTokenTypeSlashTokenTypeBangTokenTypeBangEqualTokenTypeEqualTokenTypeEqualEqualTokenTypeArrowTokenTypeLessTokenTypeLessEqualTokenTypeGreaterTokenTypeGreaterEqualTokenTypeStringTokenTypeNumberTokenTypeVarTokenTypeNilTokenTypeTrueTokenTypeFalseTokenTypeAndTokenTypeOrTokenTypeIfTokenTypeElseTokenTypeForTokenTypeWhileTokenTypeFunTokenTypeReturnTokenTypeClassTokenTypeSuperTokenTypeThisTokenTypePrintTokenTypeBreakTokenTypeContinueTokenTypeThrowTokenTypeTryTokenTypeCatchTokenTypeFinallyTokenTypeImportTokenTypeExportTokenTypeFromTokenTypeAsTokenTypeIdentifierTokenTypeEOF"
 
-var _TokenType_index = [...]uint16{0, 18, 36, 55, 73, 92, 106, 118, 136, 149, 163, 176, 190, 203, 221, 235, 254, 267, 285, 301, 322, 337, 352, 364, 376, 389, 403, 415, 426, 437, 450, 462, 476, 488, 503, 517, 531, 544, 558, 577, 589}
+var _TokenType_index = [...]uint16{0, 18, 36, 55, 73, 92, 112, 133, 147, 159, 177, 191, 204, 218, 231, 245, 258, 276, 290, 309, 323, 336, 354, 370, 391, 406, 421, 433, 445, 458, 472, 484, 495, 506, 519, 531, 545, 557, 572, 586, 600, 613, 627, 641, 658, 672, 684, 698, 714, 729, 744, 757, 768, 787, 799}
 
 func (i TokenType) String() string {
 	if i < 0 || i >= TokenType(len(_TokenType_index)-1) {
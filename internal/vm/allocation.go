@@ -0,0 +1,74 @@
+package vm
+
+import (
+	golox "golox/internal"
+	"golox/internal/interpreter"
+)
+
+// allocations counts the allocations of a run, which are capped by
+// golox.AllocationLimits.
+type allocations struct {
+	stringBytes int
+	instances   int
+	fields      int
+	scopes      int
+	elements    int
+}
+
+// allocateString counts a new string of n bytes created by n.
+func (vm *VM) allocateString(n int, expr node) error {
+	vm.runState.allocs.stringBytes += n
+	if limit := vm.limits.MaxStringBytes; limit > 0 && vm.runState.allocs.stringBytes > limit {
+		return vm.newErrorAllocationLimit(operatorOf(expr).Location, "string bytes", limit)
+	}
+	return nil
+}
+
+// allocateInstance counts a new instance of c.
+func (vm *VM) allocateInstance(c *Class) error {
+	vm.runState.allocs.instances++
+	if limit := vm.limits.MaxInstances; limit > 0 && vm.runState.allocs.instances > limit {
+		return vm.newErrorAllocationLimit(c.Location, "instances", limit)
+	}
+	return nil
+}
+
+// allocateField counts the field identifier of ins, if it is a new field.
+func (vm *VM) allocateField(ins *Instance, identifier golox.Token) error {
+	if _, ok := ins.Fields[identifier.Lexeme]; ok {
+		return nil
+	}
+
+	vm.runState.allocs.fields++
+	if limit := vm.limits.MaxFields; limit > 0 && vm.runState.allocs.fields > limit {
+		return vm.newErrorAllocationLimit(identifier.Location, "fields", limit)
+	}
+	return nil
+}
+
+// allocateScope counts a new function scope. Block scopes live on the stack,
+// so they are not counted.
+func (vm *VM) allocateScope(loc golox.Location) error {
+	vm.runState.allocs.scopes++
+	if limit := vm.limits.MaxScopes; limit > 0 && vm.runState.allocs.scopes > limit {
+		return vm.newErrorAllocationLimit(loc, "scopes", limit)
+	}
+	return nil
+}
+
+// allocateElements counts n new elements of lists or entries of maps at loc.
+func (vm *VM) allocateElements(n int, loc golox.Location) error {
+	vm.runState.allocs.elements += n
+	if limit := vm.limits.MaxElements; limit > 0 && vm.runState.allocs.elements > limit {
+		return vm.newErrorAllocationLimit(loc, "elements", limit)
+	}
+	return nil
+}
+
+// elementAllocator returns an interpreter.Allocator of the elements added at
+// loc by native methods.
+func (vm *VM) elementAllocator(loc golox.Location) interpreter.Allocator {
+	return func(n int) error {
+		return vm.allocateElements(n, loc)
+	}
+}
@@ -0,0 +1,19 @@
+package vm
+
+import golox "golox/internal"
+
+// step counts a loop iteration or a call at loc, and checks whether the run
+// should be aborted.
+func (vm *VM) step(loc golox.Location) error {
+	vm.runState.steps++
+	if vm.maxSteps > 0 && vm.runState.steps > vm.maxSteps {
+		return vm.newErrorBudgetExceeded(loc)
+	}
+
+	select {
+	case <-vm.runState.ctx.Done():
+		return vm.newErrorCancelled(loc, vm.runState.ctx.Err())
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,25 @@
+package vm
+
+import golox "golox/internal"
+
+// node is an AST node, i.e. a golox.Expression or a golox.Statement.
+type node interface {
+	GetLocation() golox.Location
+	String() string
+}
+
+// Chunk is the bytecode of a function.
+type Chunk struct {
+	Code      []byte
+	Constants []any
+	Nodes     []node // the node compiled to each byte of Code, for runtime errors
+}
+
+func (c *Chunk) write(b byte, n node) {
+	c.Code = append(c.Code, b)
+	c.Nodes = append(c.Nodes, n)
+}
+
+func (c *Chunk) readU16(offset int) int {
+	return int(c.Code[offset])<<8 | int(c.Code[offset+1])
+}
@@ -0,0 +1,1035 @@
+package vm
+
+import (
+	golox "golox/internal"
+	"math"
+)
+
+const (
+	maxLocals    = math.MaxUint8 + 1
+	maxUpvalues  = math.MaxUint8 + 1
+	maxConstants = math.MaxUint16 + 1
+	maxJump      = math.MaxUint16
+	maxElements  = math.MaxUint16
+	maxEntries   = math.MaxUint16
+)
+
+type functionKind int
+
+const (
+	functionKindScript functionKind = iota
+	functionKindFunction
+	functionKindMethod
+	functionKindInitializer
+)
+
+// local is a local variable in a stack slot of the current call.
+type local struct {
+	name       string
+	depth      int
+	isCaptured bool // by a closure, so that it is moved to the heap at the end of its scope
+}
+
+type upvalueRef struct {
+	isLocal bool // captures a local of the enclosing function, else an upvalue of it
+	index   uint8
+}
+
+// loop is a loop being compiled, for its break and continue statements.
+type loop struct {
+	scopeDepth    int   // of the loop statement
+	tryDepth      int   // number of the try statements enclosing the loop
+	breakJumps    []int // to patch to the end of the loop
+	continueJumps []int // to patch to the increment of the loop
+}
+
+// tryBlock is a try or catch block being compiled, whose finally clause runs
+// when a statement jumps out of it.
+type tryBlock struct {
+	scopeDepth int                   // of the try statement
+	loopDepth  int                   // number of the loops enclosing the try statement
+	finally    *golox.StatementBlock // nil if there is no finally clause
+}
+
+// functionCompiler compiles the body of a function.
+type functionCompiler struct {
+	enclosing  *functionCompiler
+	function   *Function
+	kind       functionKind
+	locals     []local
+	upvalues   []upvalueRef
+	scopeDepth int
+	loops      []*loop     // enclosing the current statement, innermost last
+	tries      []*tryBlock // enclosing the current statement, innermost last
+	constants  map[any]int // to reuse the index of equal constants
+}
+
+// Compiler compiles resolved statements to a Function for the VM. Variables
+// are looked up by name at compile time, the same way as the resolver.
+type Compiler struct {
+	// configs:
+	isDebug bool
+	logger  *golox.Logger
+
+	// states:
+	currFunction *functionCompiler
+}
+
+func (c *Compiler) chunk() *Chunk {
+	return &c.currFunction.function.Chunk
+}
+
+func (c *Compiler) emit(n node, bytes ...byte) {
+	for _, b := range bytes {
+		c.chunk().write(b, n)
+	}
+}
+
+func (c *Compiler) emitOp(op OpCode, n node) {
+	c.chunk().write(byte(op), n)
+}
+
+func (c *Compiler) emitU16(v int, n node) {
+	c.emit(n, byte(v>>8), byte(v))
+}
+
+func (c *Compiler) emitOpU16(op OpCode, v int, n node) {
+	c.emitOp(op, n)
+	c.emitU16(v, n)
+}
+
+// emitJump emits a jump with a placeholder offset, and returns the position of
+// the offset to patch.
+func (c *Compiler) emitJump(op OpCode, n node) int {
+	c.emitOp(op, n)
+	c.emitU16(0xffff, n)
+	return len(c.chunk().Code) - 2
+}
+
+// patchJump makes the jump at offset land on the next instruction.
+func (c *Compiler) patchJump(offset int, n node) error {
+	jump := len(c.chunk().Code) - offset - 2
+	if jump > maxJump {
+		return c.newErrorJumpTooLarge(n)
+	}
+	c.chunk().Code[offset] = byte(jump >> 8)
+	c.chunk().Code[offset+1] = byte(jump)
+	return nil
+}
+
+func (c *Compiler) emitLoop(start int, n node) error {
+	c.emitOp(OpLoop, n)
+	jump := len(c.chunk().Code) - start + 2
+	if jump > maxJump {
+		return c.newErrorJumpTooLarge(n)
+	}
+	c.emitU16(jump, n)
+	return nil
+}
+
+func (c *Compiler) emitReturn(n node) {
+	if c.currFunction.kind == functionKindInitializer {
+		c.emit(n, byte(OpGetLocal), 0)
+	} else {
+		c.emitOp(OpNil, n)
+	}
+	c.emitOp(OpReturn, n)
+}
+
+func (c *Compiler) makeConstant(val any, n node) (int, error) {
+	fc := c.currFunction
+	if _, ok := val.(*Function); !ok {
+		if index, ok := fc.constants[val]; ok {
+			return index, nil
+		}
+	}
+
+	index := len(fc.function.Chunk.Constants)
+	if index >= maxConstants {
+		return 0, c.newErrorTooManyConstants(n)
+	}
+	fc.function.Chunk.Constants = append(fc.function.Chunk.Constants, val)
+	if _, ok := val.(*Function); !ok {
+		fc.constants[val] = index
+	}
+	return index, nil
+}
+
+func (c *Compiler) emitConstant(val any, n node) error {
+	if index, err := c.makeConstant(val, n); err != nil {
+		return err
+	} else {
+		c.emitOpU16(OpConstant, index, n)
+		return nil
+	}
+}
+
+func (c *Compiler) beginFunction(kind functionKind, fn *Function) {
+	fc := &functionCompiler{
+		enclosing:  c.currFunction,
+		function:   fn,
+		kind:       kind,
+		locals:     make([]local, 1, 8),
+		upvalues:   nil,
+		scopeDepth: 0,
+		loops:      nil,
+		tries:      nil,
+		constants:  map[any]int{},
+	}
+	// slot 0 holds the callee, or the instance of a method
+	if kind == functionKindMethod || kind == functionKindInitializer {
+		fc.locals[0] = local{name: "this", depth: 0, isCaptured: false}
+	}
+	c.currFunction = fc
+}
+
+func (c *Compiler) endFunction() *Function {
+	fn := c.currFunction.function
+	fn.UpvalueCount = len(c.currFunction.upvalues)
+	c.logCompiledFunction(fn)
+	c.currFunction = c.currFunction.enclosing
+	return fn
+}
+
+func (c *Compiler) beginScope() {
+	c.currFunction.scopeDepth++
+}
+
+func (c *Compiler) endScope(n node) {
+	fc := c.currFunction
+	fc.scopeDepth--
+	c.emitPopLocals(fc.scopeDepth, n)
+	c.dropLocals()
+}
+
+// emitPopLocals emits the pops of the locals deeper than depth, without
+// removing them from the compiler, e.g. for jumping out of their scopes.
+func (c *Compiler) emitPopLocals(depth int, n node) {
+	fc := c.currFunction
+	for i := len(fc.locals) - 1; i >= 0 && fc.locals[i].depth > depth; i-- {
+		if fc.locals[i].isCaptured {
+			c.emitOp(OpCloseUpvalue, n)
+		} else {
+			c.emitOp(OpPop, n)
+		}
+	}
+}
+
+// dropLocals removes the locals deeper than the current scope from the
+// compiler, without emitting their pops, e.g. for code not falling through.
+func (c *Compiler) dropLocals() {
+	fc := c.currFunction
+	for len(fc.locals) > 0 && fc.locals[len(fc.locals)-1].depth > fc.scopeDepth {
+		fc.locals = fc.locals[:len(fc.locals)-1]
+	}
+}
+
+// addHiddenLocal adds a local holding a value of the VM on the stack, which
+// can not be referred to by name.
+func (c *Compiler) addHiddenLocal(n node) error {
+	return c.addLocal(golox.Token{
+		Location:     n.GetLocation(),
+		TokenType:    golox.TokenTypeIdentifier,
+		LiteralValue: nil,
+		Lexeme:       "",
+	})
+}
+
+func (c *Compiler) addLocal(identifier golox.Token) error {
+	fc := c.currFunction
+	if len(fc.locals) >= maxLocals {
+		return c.newErrorTooManyLocals(identifier)
+	}
+	fc.locals = append(fc.locals, local{
+		name:       identifier.Lexeme,
+		depth:      fc.scopeDepth,
+		isCaptured: false,
+	})
+	return nil
+}
+
+// defineVariable defines the variable identifier with the value on top of the
+// stack, which becomes its slot if the variable is local.
+func (c *Compiler) defineVariable(identifier golox.Token, n node) error {
+	if c.currFunction.scopeDepth > 0 {
+		return c.addLocal(identifier)
+	} else if index, err := c.makeConstant(identifier.Lexeme, n); err != nil {
+		return err
+	} else {
+		c.emitOpU16(OpDefineGlobal, index, n)
+		return nil
+	}
+}
+
+func resolveLocal(fc *functionCompiler, name string) int {
+	for i := len(fc.locals) - 1; i >= 0; i-- {
+		if fc.locals[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *Compiler) resolveUpvalue(fc *functionCompiler, identifier golox.Token) (int, error) {
+	if fc.enclosing == nil {
+		return -1, nil
+	} else if index := resolveLocal(fc.enclosing, identifier.Lexeme); index >= 0 {
+		fc.enclosing.locals[index].isCaptured = true
+		return c.addUpvalue(fc, identifier, upvalueRef{isLocal: true, index: uint8(index)})
+	} else if index, err := c.resolveUpvalue(fc.enclosing, identifier); err != nil || index < 0 {
+		return index, err
+	} else {
+		return c.addUpvalue(fc, identifier, upvalueRef{isLocal: false, index: uint8(index)})
+	}
+}
+
+func (c *Compiler) addUpvalue(fc *functionCompiler, identifier golox.Token, ref upvalueRef) (int, error) {
+	for i, upvalue := range fc.upvalues {
+		if upvalue == ref {
+			return i, nil
+		}
+	}
+	if len(fc.upvalues) >= maxUpvalues {
+		return 0, c.newErrorTooManyUpvalues(identifier)
+	}
+	fc.upvalues = append(fc.upvalues, ref)
+	return len(fc.upvalues) - 1, nil
+}
+
+// thisToken is the implicit "this" of expr.
+func thisToken(expr *golox.ExpressionSuper) golox.Token {
+	tkn := expr.SuperToken
+	tkn.TokenType = golox.TokenTypeThis
+	tkn.Lexeme = "this"
+	return tkn
+}
+
+// emitGetVariable emits the instruction getting the variable identifier, which
+// is local, captured or global.
+func (c *Compiler) emitGetVariable(identifier golox.Token, n node) error {
+	return c.emitVariable(identifier, n, OpGetLocal, OpGetUpvalue, OpGetGlobal)
+}
+
+func (c *Compiler) emitSetVariable(identifier golox.Token, n node) error {
+	return c.emitVariable(identifier, n, OpSetLocal, OpSetUpvalue, OpSetGlobal)
+}
+
+func (c *Compiler) emitVariable(
+	identifier golox.Token,
+	n node,
+	localOp OpCode,
+	upvalueOp OpCode,
+	globalOp OpCode,
+) error {
+	if index := resolveLocal(c.currFunction, identifier.Lexeme); index >= 0 {
+		c.emit(n, byte(localOp), byte(index))
+		return nil
+	} else if index, err := c.resolveUpvalue(c.currFunction, identifier); err != nil {
+		return err
+	} else if index >= 0 {
+		c.emit(n, byte(upvalueOp), byte(index))
+		return nil
+	} else if index, err := c.makeConstant(identifier.Lexeme, n); err != nil {
+		return err
+	} else {
+		c.emitOpU16(globalOp, index, n)
+		return nil
+	}
+}
+
+func (c *Compiler) compileFunction(kind functionKind, stmt *golox.StatementFun, className string) error {
+	c.beginFunction(kind, &Function{
+		Name:         stmt.Identifier.Lexeme,
+		ClassName:    className,
+		Location:     stmt.Identifier.Location,
+		Arity:        len(stmt.Parameters),
+		UpvalueCount: 0,
+		Chunk:        Chunk{},
+	})
+	c.beginScope()
+	for _, param := range stmt.Parameters {
+		if err := c.addLocal(param); err != nil {
+			return err
+		}
+	}
+	for _, stmt := range stmt.Body {
+		if err := c.compileStatement(stmt); err != nil {
+			return err
+		}
+	}
+	c.emitReturn(stmt)
+	upvalues := c.currFunction.upvalues
+	fn := c.endFunction()
+
+	if index, err := c.makeConstant(fn, stmt); err != nil {
+		return err
+	} else {
+		c.emitOpU16(OpClosure, index, stmt)
+		for _, upvalue := range upvalues {
+			if upvalue.isLocal {
+				c.emit(stmt, 1, upvalue.index)
+			} else {
+				c.emit(stmt, 0, upvalue.index)
+			}
+		}
+		return nil
+	}
+}
+
+func (c *Compiler) compileArguments(args []golox.Expression) error {
+	for _, arg := range args {
+		if err := c.compileExpression(arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) compileExpression(expr golox.Expression) error {
+	switch expr := expr.(type) {
+	case nil:
+		c.emitOp(OpNil, &golox.ExpressionLiteral{})
+
+	case *golox.ExpressionLiteral:
+		switch val := expr.LiteralValue.(type) {
+		case nil:
+			c.emitOp(OpNil, expr)
+		case bool:
+			if val {
+				c.emitOp(OpTrue, expr)
+			} else {
+				c.emitOp(OpFalse, expr)
+			}
+		default:
+			return c.emitConstant(val, expr)
+		}
+
+	case *golox.ExpressionGrouping:
+		return c.compileExpression(expr.Expression)
+
+	case *golox.ExpressionVariable:
+		return c.emitGetVariable(expr.Identifier, expr)
+
+	case *golox.ExpressionCall:
+		// method calls skip creating a bound method
+		if get, ok := expr.Callee.(*golox.ExpressionGet); ok {
+			if err := c.compileExpression(get.Object); err != nil {
+				return err
+			} else if err := c.compileArguments(expr.Arguments); err != nil {
+				return err
+			} else if index, err := c.makeConstant(get.Identifier.Lexeme, expr); err != nil {
+				return err
+			} else {
+				c.emitOpU16(OpInvoke, index, expr)
+				c.emit(expr, byte(len(expr.Arguments)))
+				return nil
+			}
+		} else if super, ok := expr.Callee.(*golox.ExpressionSuper); ok {
+			if err := c.emitGetVariable(thisToken(super), super); err != nil {
+				return err
+			} else if err := c.compileArguments(expr.Arguments); err != nil {
+				return err
+			} else if err := c.emitGetVariable(super.SuperToken, super); err != nil {
+				return err
+			} else if index, err := c.makeConstant(super.Method.Lexeme, expr); err != nil {
+				return err
+			} else {
+				c.emitOpU16(OpSuperInvoke, index, expr)
+				c.emit(expr, byte(len(expr.Arguments)))
+				return nil
+			}
+		} else if err := c.compileExpression(expr.Callee); err != nil {
+			return err
+		} else if err := c.compileArguments(expr.Arguments); err != nil {
+			return err
+		} else {
+			c.emit(expr, byte(OpCall), byte(len(expr.Arguments)))
+		}
+
+	case *golox.ExpressionGet:
+		if err := c.compileExpression(expr.Object); err != nil {
+			return err
+		} else if index, err := c.makeConstant(expr.Identifier.Lexeme, expr); err != nil {
+			return err
+		} else {
+			c.emitOpU16(OpGetProperty, index, expr)
+		}
+
+	case *golox.ExpressionSet:
+		if err := c.compileExpression(expr.Object); err != nil {
+			return err
+		} else if err := c.compileExpression(expr.Value); err != nil {
+			return err
+		} else if index, err := c.makeConstant(expr.Identifier.Lexeme, expr); err != nil {
+			return err
+		} else {
+			c.emitOpU16(OpSetProperty, index, expr)
+		}
+
+	case *golox.ExpressionThis:
+		return c.emitGetVariable(expr.ThisToken, expr)
+
+	case *golox.ExpressionSuper:
+		if err := c.emitGetVariable(thisToken(expr), expr); err != nil {
+			return err
+		} else if err := c.emitGetVariable(expr.SuperToken, expr); err != nil {
+			return err
+		} else if index, err := c.makeConstant(expr.Method.Lexeme, expr); err != nil {
+			return err
+		} else {
+			c.emitOpU16(OpGetSuper, index, expr)
+		}
+
+	case *golox.ExpressionUnary:
+		if err := c.compileExpression(expr.Right); err != nil {
+			return err
+		}
+		switch expr.Operator.TokenType {
+		case golox.TokenTypeMinus:
+			c.emitOp(OpNegate, expr)
+		case golox.TokenTypeBang:
+			c.emitOp(OpNot, expr)
+		default:
+			return c.newErrorMissingImplementation(expr)
+		}
+
+	case *golox.ExpressionBinary:
+		if err := c.compileExpression(expr.Left); err != nil {
+			return err
+		} else if err := c.compileExpression(expr.Right); err != nil {
+			return err
+		}
+		switch expr.Operator.TokenType {
+		case golox.TokenTypeGreater:
+			c.emitOp(OpGreater, expr)
+		case golox.TokenTypeGreaterEqual:
+			c.emitOp(OpGreaterEqual, expr)
+		case golox.TokenTypeLess:
+			c.emitOp(OpLess, expr)
+		case golox.TokenTypeLessEqual:
+			c.emitOp(OpLessEqual, expr)
+		case golox.TokenTypeBangEqual:
+			c.emitOp(OpNotEqual, expr)
+		case golox.TokenTypeEqualEqual:
+			c.emitOp(OpEqual, expr)
+		case golox.TokenTypeMinus:
+			c.emitOp(OpSubtract, expr)
+		case golox.TokenTypePlus:
+			c.emitOp(OpAdd, expr)
+		case golox.TokenTypeSlash:
+			c.emitOp(OpDivide, expr)
+		case golox.TokenTypeStar:
+			c.emitOp(OpMultiply, expr)
+		default:
+			return c.newErrorMissingImplementation(expr)
+		}
+
+	case *golox.ExpressionLogical:
+		if err := c.compileExpression(expr.Left); err != nil {
+			return err
+		}
+		if expr.Operator.TokenType == golox.TokenTypeOr {
+			elseJump := c.emitJump(OpJumpIfFalse, expr)
+			endJump := c.emitJump(OpJump, expr)
+			if err := c.patchJump(elseJump, expr); err != nil {
+				return err
+			}
+			c.emitOp(OpPop, expr)
+			if err := c.compileExpression(expr.Right); err != nil {
+				return err
+			}
+			return c.patchJump(endJump, expr)
+		} else {
+			endJump := c.emitJump(OpJumpIfFalse, expr)
+			c.emitOp(OpPop, expr)
+			if err := c.compileExpression(expr.Right); err != nil {
+				return err
+			}
+			return c.patchJump(endJump, expr)
+		}
+
+	case *golox.ExpressionAssignment:
+		if err := c.compileExpression(expr.Value); err != nil {
+			return err
+		}
+		return c.emitSetVariable(expr.Identifier, expr)
+
+	case *golox.ExpressionFunction:
+		return c.compileFunction(functionKindFunction, expr.Declaration, "")
+
+	case *golox.ExpressionList:
+		if len(expr.Elements) > maxElements {
+			return c.newErrorTooManyElements(expr)
+		} else if err := c.compileArguments(expr.Elements); err != nil {
+			return err
+		}
+		c.emitOpU16(OpList, len(expr.Elements), expr)
+
+	case *golox.ExpressionMap:
+		if len(expr.Keys) > maxEntries {
+			return c.newErrorTooManyEntries(expr)
+		}
+		for i, key := range expr.Keys {
+			if err := c.compileExpression(key); err != nil {
+				return err
+			} else if err := c.compileExpression(expr.Values[i]); err != nil {
+				return err
+			}
+		}
+		c.emitOpU16(OpMap, len(expr.Keys), expr)
+
+	case *golox.ExpressionIndex:
+		if err := c.compileExpression(expr.Object); err != nil {
+			return err
+		} else if err := c.compileExpression(expr.Index); err != nil {
+			return err
+		}
+		c.emitOp(OpGetIndex, expr)
+
+	case *golox.ExpressionIndexSet:
+		if err := c.compileExpression(expr.Object); err != nil {
+			return err
+		} else if err := c.compileExpression(expr.Index); err != nil {
+			return err
+		} else if err := c.compileExpression(expr.Value); err != nil {
+			return err
+		}
+		c.emitOp(OpSetIndex, expr)
+
+	default:
+		return c.newErrorMissingImplementation(expr)
+	}
+	return nil
+}
+
+func (c *Compiler) compileStatement(stmt golox.Statement) error {
+	switch stmt := stmt.(type) {
+	case nil:
+		break
+
+	case *golox.StatementExpression:
+		if err := c.compileExpression(stmt.Expression); err != nil {
+			return err
+		}
+		c.emitOp(OpPop, stmt)
+
+	case *golox.StatementBlock:
+		c.beginScope()
+		for _, stmt := range stmt.Statements {
+			if err := c.compileStatement(stmt); err != nil {
+				return err
+			}
+		}
+		c.endScope(stmt)
+
+	case *golox.StatementVar:
+		if stmt.Expression == nil {
+			c.emitOp(OpNil, stmt)
+		} else if err := c.compileExpression(stmt.Expression); err != nil {
+			return err
+		}
+		return c.defineVariable(stmt.Identifier, stmt)
+
+	case *golox.StatementFun:
+		// declared before compiling the body, for recursion
+		if c.currFunction.scopeDepth > 0 {
+			if err := c.addLocal(stmt.Identifier); err != nil {
+				return err
+			} else if err := c.compileFunction(functionKindFunction, stmt, ""); err != nil {
+				return err
+			}
+			return nil
+		} else if err := c.compileFunction(functionKindFunction, stmt, ""); err != nil {
+			return err
+		} else {
+			return c.defineVariable(stmt.Identifier, stmt)
+		}
+
+	case *golox.StatementReturn:
+		fc := c.currFunction
+		if stmt.Expression == nil {
+			if err := c.emitExitTries(0, stmt); err != nil {
+				return err
+			}
+			c.emitReturn(stmt)
+		} else if err := c.compileExpression(stmt.Expression); err != nil {
+			return err
+		} else if len(fc.tries) == 0 {
+			c.emitOp(OpReturn, stmt)
+		} else {
+			// the returned value is kept in a slot while the finally clauses run
+			slot := len(fc.locals)
+			if err := c.addHiddenLocal(stmt); err != nil {
+				return err
+			} else if err := c.emitExitTries(0, stmt); err != nil {
+				return err
+			}
+			c.emit(stmt, byte(OpGetLocal), byte(slot))
+			c.emitOp(OpReturn, stmt)
+			fc.locals = fc.locals[:slot]
+		}
+
+	case *golox.StatementClass:
+		return c.compileClass(stmt)
+
+	case *golox.StatementPrint:
+		if err := c.compileExpression(stmt.Expression); err != nil {
+			return err
+		}
+		c.emitOp(OpPrint, stmt)
+
+	case *golox.StatementIf:
+		if err := c.compileExpression(stmt.Condition); err != nil {
+			return err
+		}
+		thenJump := c.emitJump(OpJumpIfFalse, stmt)
+		c.emitOp(OpPop, stmt)
+		if err := c.compileStatement(stmt.Then); err != nil {
+			return err
+		}
+		elseJump := c.emitJump(OpJump, stmt)
+		if err := c.patchJump(thenJump, stmt); err != nil {
+			return err
+		}
+		c.emitOp(OpPop, stmt)
+		if stmt.Else != nil {
+			if err := c.compileStatement(stmt.Else); err != nil {
+				return err
+			}
+		}
+		return c.patchJump(elseJump, stmt)
+
+	case *golox.StatementWhile:
+		return c.compileWhile(stmt)
+
+	case *golox.StatementBreak:
+		l := c.currFunction.loops[len(c.currFunction.loops)-1]
+		if err := c.emitExitTries(l.tryDepth, stmt); err != nil {
+			return err
+		}
+		c.emitPopLocals(l.scopeDepth, stmt)
+		l.breakJumps = append(l.breakJumps, c.emitJump(OpJump, stmt))
+
+	case *golox.StatementContinue:
+		l := c.currFunction.loops[len(c.currFunction.loops)-1]
+		if err := c.emitExitTries(l.tryDepth, stmt); err != nil {
+			return err
+		}
+		c.emitPopLocals(l.scopeDepth, stmt)
+		l.continueJumps = append(l.continueJumps, c.emitJump(OpJump, stmt))
+
+	case *golox.StatementThrow:
+		if err := c.compileExpression(stmt.Expression); err != nil {
+			return err
+		}
+		c.emitOp(OpThrow, stmt)
+
+	case *golox.StatementTry:
+		return c.compileTry(stmt)
+
+	case *golox.StatementImport:
+		if stmt.Names == nil {
+			c.emitOp(OpImport, stmt)
+			return c.defineVariable(stmt.Alias, stmt)
+		}
+		// the module is cached, so that importing it again for each name is cheap
+		for _, name := range stmt.Names {
+			if index, err := c.makeConstant(name.Lexeme, stmt); err != nil {
+				return err
+			} else {
+				c.emitOp(OpImport, stmt)
+				c.emitOpU16(OpGetExport, index, stmt)
+			}
+			if err := c.defineVariable(name, stmt); err != nil {
+				return err
+			}
+		}
+
+	case *golox.StatementExport:
+		return c.compileStatement(stmt.Declaration)
+
+	default:
+		return c.newErrorMissingImplementation(stmt)
+	}
+	return nil
+}
+
+func (c *Compiler) compileWhile(stmt *golox.StatementWhile) error {
+	fc := c.currFunction
+	l := &loop{scopeDepth: fc.scopeDepth, tryDepth: len(fc.tries), breakJumps: nil, continueJumps: nil}
+	fc.loops = append(fc.loops, l)
+	defer func() { fc.loops = fc.loops[:len(fc.loops)-1] }()
+
+	loopStart := len(c.chunk().Code)
+	if err := c.compileExpression(stmt.Condition); err != nil {
+		return err
+	}
+	exitJump := c.emitJump(OpJumpIfFalse, stmt)
+	c.emitOp(OpPop, stmt)
+	if err := c.compileStatement(stmt.Body); err != nil {
+		return err
+	}
+
+	// continue runs the increment of a for loop
+	for _, jump := range l.continueJumps {
+		if err := c.patchJump(jump, stmt); err != nil {
+			return err
+		}
+	}
+	if stmt.Increment != nil {
+		if err := c.compileExpression(stmt.Increment); err != nil {
+			return err
+		}
+		c.emitOp(OpPop, stmt)
+	}
+
+	if err := c.emitLoop(loopStart, stmt); err != nil {
+		return err
+	} else if err := c.patchJump(exitJump, stmt); err != nil {
+		return err
+	}
+	c.emitOp(OpPop, stmt)
+
+	// break skips the pop of the condition, which is popped when entering the body
+	for _, jump := range l.breakJumps {
+		if err := c.patchJump(jump, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileTry compiles stmt as its body followed by its finally clause, with
+// the handlers of the errors raised in the body and in the catch clause:
+//
+//	OP_TRY[_FINALLY] -> catch   ; finally if there is no catch clause
+//	body, OP_POP_TRY, finally, OP_JUMP -> end
+//	catch: OP_TRY_FINALLY -> rethrow
+//	catch body, OP_POP_TRY, finally, OP_JUMP -> end
+//	rethrow: finally, OP_RETHROW
+//	end:
+func (c *Compiler) compileTry(stmt *golox.StatementTry) error {
+	fc := c.currFunction
+	block := &tryBlock{scopeDepth: fc.scopeDepth, loopDepth: len(fc.loops), finally: stmt.Finally}
+
+	op := OpTry
+	if stmt.Catch == nil {
+		op = OpTryFinally
+	}
+	handlerJump := c.emitJump(op, stmt)
+	if err := c.compileTryBlock(block, stmt.Body, stmt); err != nil {
+		return err
+	}
+	endJumps := []int{c.emitJump(OpJump, stmt)}
+	if err := c.patchJump(handlerJump, stmt); err != nil {
+		return err
+	}
+
+	if stmt.Catch != nil {
+		// the caught value is on top of the stack
+		c.beginScope()
+		if err := c.addLocal(stmt.CatchIdentifier); err != nil {
+			return err
+		}
+		if stmt.Finally == nil {
+			if err := c.compileStatement(stmt.Catch); err != nil {
+				return err
+			}
+			c.endScope(stmt)
+			return c.patchJump(endJumps[0], stmt)
+		}
+
+		handlerJump = c.emitJump(OpTryFinally, stmt)
+		fc.tries = append(fc.tries, block)
+		err := c.compileStatement(stmt.Catch)
+		fc.tries = fc.tries[:len(fc.tries)-1]
+		if err != nil {
+			return err
+		}
+		c.emitOp(OpPopTry, stmt)
+		c.endScope(stmt)
+		if err := c.compileFinally(len(fc.tries), block, stmt); err != nil {
+			return err
+		}
+		endJumps = append(endJumps, c.emitJump(OpJump, stmt))
+		if err := c.patchJump(handlerJump, stmt); err != nil {
+			return err
+		}
+
+		// the caught value is kept below the pending error
+		c.beginScope()
+		if err := c.addHiddenLocal(stmt); err != nil {
+			return err
+		}
+	} else {
+		c.beginScope()
+	}
+
+	// the pending error is on top of the stack
+	slot := len(fc.locals)
+	if err := c.addHiddenLocal(stmt); err != nil {
+		return err
+	} else if err := c.compileStatement(stmt.Finally); err != nil {
+		return err
+	}
+	c.emit(stmt, byte(OpGetLocal), byte(slot))
+	c.emitOp(OpRethrow, stmt)
+	fc.scopeDepth--
+	c.dropLocals()
+
+	for _, jump := range endJumps {
+		if err := c.patchJump(jump, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileTryBlock compiles body within the handler of block, then pops the
+// handler and runs the finally clause.
+func (c *Compiler) compileTryBlock(block *tryBlock, body *golox.StatementBlock, n node) error {
+	fc := c.currFunction
+	fc.tries = append(fc.tries, block)
+	err := c.compileStatement(body)
+	fc.tries = fc.tries[:len(fc.tries)-1]
+	if err != nil {
+		return err
+	}
+	c.emitOp(OpPopTry, n)
+	return c.compileFinally(len(fc.tries), block, n)
+}
+
+// emitExitTries emits the exits of the try blocks enclosing the current
+// statement down to the depth downTo, innermost first, for jumping out of
+// them.
+func (c *Compiler) emitExitTries(downTo int, n node) error {
+	fc := c.currFunction
+	for i := len(fc.tries) - 1; i >= downTo; i-- {
+		c.emitOp(OpPopTry, n)
+		if err := c.compileFinally(i, fc.tries[i], n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileFinally compiles the finally clause of block, the depth-th try block
+// of the current function, where the try statement is. The locals declared
+// in the try statement are hidden from it, but kept on the stack.
+func (c *Compiler) compileFinally(depth int, block *tryBlock, n node) error {
+	if block.finally == nil {
+		return nil
+	}
+	fc := c.currFunction
+	tries, loops := fc.tries, fc.loops
+	// capped, so that appending to them does not overwrite the enclosing ones
+	fc.tries, fc.loops = fc.tries[:depth:depth], fc.loops[:block.loopDepth:block.loopDepth]
+	defer func() { fc.tries, fc.loops = tries, loops }()
+
+	var names []string
+	for i := range fc.locals {
+		names = append(names, fc.locals[i].name)
+		if fc.locals[i].depth > block.scopeDepth {
+			fc.locals[i].name = ""
+		}
+	}
+	defer func() {
+		for i := range names {
+			fc.locals[i].name = names[i]
+		}
+	}()
+	return c.compileStatement(block.finally)
+}
+
+func (c *Compiler) compileClass(stmt *golox.StatementClass) error {
+	nameIndex, err := c.makeConstant(stmt.Identifier.Lexeme, stmt)
+	if err != nil {
+		return err
+	}
+	c.emitOpU16(OpClass, nameIndex, stmt)
+	if err := c.defineVariable(stmt.Identifier, stmt); err != nil {
+		return err
+	}
+
+	if stmt.Superclass != nil {
+		if err := c.emitGetVariable(stmt.Superclass.Identifier, stmt.Superclass); err != nil {
+			return err
+		}
+		// the superclass stays on the stack as the local "super" of the methods
+		c.beginScope()
+		if err := c.addLocal(golox.Token{Lexeme: "super", Location: stmt.Identifier.Location}); err != nil {
+			return err
+		} else if err := c.emitGetVariable(stmt.Identifier, stmt); err != nil {
+			return err
+		}
+		c.emitOp(OpInherit, stmt.Superclass)
+	}
+
+	if err := c.emitGetVariable(stmt.Identifier, stmt); err != nil {
+		return err
+	}
+	for _, method := range stmt.Methods {
+		kind := functionKindMethod
+		if method.Identifier.Lexeme == "init" {
+			kind = functionKindInitializer
+		}
+		if err := c.compileFunction(kind, method, stmt.Identifier.Lexeme); err != nil {
+			return err
+		} else if index, err := c.makeConstant(method.Identifier.Lexeme, method); err != nil {
+			return err
+		} else {
+			c.emitOpU16(OpMethod, index, method)
+		}
+	}
+	c.emitOp(OpPop, stmt)
+
+	if stmt.Superclass != nil {
+		c.endScope(stmt)
+	}
+	return nil
+}
+
+// CompileStatements compiles stmts to the script function of a run. If the
+// last statement is an expression statement, the script returns its value.
+func (c *Compiler) CompileStatements(stmts []golox.Statement) (*Function, error) {
+	c.currFunction = nil
+	c.beginFunction(functionKindScript, &Function{
+		Name:         "",
+		ClassName:    "",
+		Location:     golox.Location{},
+		Arity:        0,
+		UpvalueCount: 0,
+		Chunk:        Chunk{},
+	})
+
+	for i, stmt := range stmts {
+		if stmt, ok := stmt.(*golox.StatementExpression); ok && i == len(stmts)-1 {
+			if err := c.compileExpression(stmt.Expression); err != nil {
+				return nil, err
+			}
+			c.emitOp(OpReturn, stmt)
+			return c.endFunction(), nil
+		}
+
+		if err := c.compileStatement(stmt); err != nil {
+			return nil, err
+		}
+	}
+
+	var end node = &golox.StatementBlock{}
+	if len(stmts) > 0 {
+		end = stmts[len(stmts)-1]
+	}
+	c.emitReturn(end)
+	return c.endFunction(), nil
+}
+
+func NewCompiler(
+	config golox.Config,
+) *Compiler {
+	return &Compiler{
+		isDebug:      config.IsDebug,
+		logger:       golox.NewLogger(config.IsDebug, config.StderrWriter()),
+		currFunction: nil,
+	}
+}
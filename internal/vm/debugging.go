@@ -0,0 +1,414 @@
+package vm
+
+import (
+	"fmt"
+	golox "golox/internal"
+	"golox/internal/interpreter"
+	"strings"
+)
+
+// disassemble returns the instructions of fn.
+func disassemble(fn *Function) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "== %s ==\n", fn)
+	for offset := 0; offset < len(fn.Chunk.Code); {
+		var line string
+		line, offset = disassembleInstruction(&fn.Chunk, offset)
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// disassembleInstruction returns the instruction at offset, and the offset of
+// the next instruction.
+func disassembleInstruction(chunk *Chunk, offset int) (string, int) {
+	op := OpCode(chunk.Code[offset])
+	prefix := fmt.Sprintf("%04d %4d %-18s", offset, chunk.Nodes[offset].GetLocation().Line, op)
+	switch op {
+	case OpConstant, OpGetGlobal, OpDefineGlobal, OpSetGlobal, OpGetProperty, OpSetProperty,
+		OpGetSuper, OpClass, OpMethod, OpGetExport:
+		index := chunk.readU16(offset + 1)
+		return fmt.Sprintf("%s %4d %s", prefix, index, interpreter.Stringify(chunk.Constants[index])), offset + 3
+	case OpGetLocal, OpSetLocal, OpGetUpvalue, OpSetUpvalue, OpCall:
+		return fmt.Sprintf("%s %4d", prefix, chunk.Code[offset+1]), offset + 2
+	case OpList, OpMap:
+		return fmt.Sprintf("%s %4d", prefix, chunk.readU16(offset+1)), offset + 3
+	case OpJump, OpJumpIfFalse, OpTry, OpTryFinally:
+		return fmt.Sprintf("%s %4d -> %d", prefix, offset, offset+3+chunk.readU16(offset+1)), offset + 3
+	case OpLoop:
+		return fmt.Sprintf("%s %4d -> %d", prefix, offset, offset+3-chunk.readU16(offset+1)), offset + 3
+	case OpInvoke, OpSuperInvoke:
+		index := chunk.readU16(offset + 1)
+		return fmt.Sprintf("%s (%d args) %4d %s", prefix, chunk.Code[offset+3], index, chunk.Constants[index]), offset + 4
+	case OpClosure:
+		index := chunk.readU16(offset + 1)
+		fn := chunk.Constants[index].(*Function)
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s %4d %s", prefix, index, fn)
+		offset += 3
+		for i := 0; i < fn.UpvalueCount; i++ {
+			kind := "upvalue"
+			if chunk.Code[offset] == 1 {
+				kind = "local"
+			}
+			fmt.Fprintf(&b, "\n%04d    |                    %s %d", offset, kind, chunk.Code[offset+1])
+			offset += 2
+		}
+		return b.String(), offset
+	default:
+		return strings.TrimRight(prefix, " "), offset + 1
+	}
+}
+
+func (c *Compiler) logCompiledFunction(fn *Function) {
+	if c.isDebug {
+		c.logger.Logf(golox.ModuleCompiler, "compiled %s\n%s", fn, disassemble(fn))
+	}
+}
+
+func (vm *VM) logInstruction(chunk *Chunk, offset int) {
+	if vm.isDebug {
+		line, _ := disassembleInstruction(chunk, offset)
+		var b strings.Builder
+		for _, val := range vm.stack[:vm.sp] {
+			b.WriteString("[ ")
+			b.WriteString(interpreter.Stringify(val))
+			b.WriteString(" ]")
+		}
+		vm.logger.Logf(golox.ModuleVM, "%-48s stack: %s", line, b.String())
+	}
+}
+
+// identifierOf returns the variable token of n, for errors on variables.
+func identifierOf(n node) golox.Token {
+	switch n := n.(type) {
+	case *golox.ExpressionVariable:
+		return n.Identifier
+	case *golox.ExpressionAssignment:
+		return n.Identifier
+	case *golox.StatementClass:
+		return n.Identifier
+	default:
+		return golox.Token{Location: n.GetLocation(), Lexeme: n.String()}
+	}
+}
+
+// operatorOf returns the operator token of n, for errors on operands.
+func operatorOf(n node) golox.Token {
+	switch n := n.(type) {
+	case *golox.ExpressionUnary:
+		return n.Operator
+	case *golox.ExpressionBinary:
+		return n.Operator
+	default:
+		return golox.Token{Location: n.GetLocation(), Lexeme: n.String()}
+	}
+}
+
+// importedName returns the token of name in the names imported by stmt, for
+// errors on exports.
+func importedName(stmt *golox.StatementImport, name string) golox.Token {
+	for _, identifier := range stmt.Names {
+		if identifier.Lexeme == name {
+			return identifier
+		}
+	}
+	return golox.Token{Location: stmt.GetLocation(), Lexeme: name}
+}
+
+func (c *Compiler) newErrorTooManyLocals(
+	identifier golox.Token,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseCompiler, golox.ErrorCodeTooManyLocals,
+		identifier,
+		"too many local variables in a function, the max is %d", maxLocals,
+	)
+}
+
+func (c *Compiler) newErrorTooManyUpvalues(
+	identifier golox.Token,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseCompiler, golox.ErrorCodeTooManyUpvalues,
+		identifier,
+		"too many closure variables in a function, the max is %d", maxUpvalues,
+	)
+}
+
+func (c *Compiler) newErrorTooManyConstants(
+	n node,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseCompiler, golox.ErrorCodeTooManyConstants,
+		n.GetLocation(), golox.Location{},
+		"too many constants in a function, the max is %d", maxConstants,
+	)
+}
+
+func (c *Compiler) newErrorJumpTooLarge(
+	n node,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseCompiler, golox.ErrorCodeJumpTooLarge,
+		n.GetLocation(), golox.Location{},
+		"too much code to jump over, the max is %d bytes", maxJump,
+	)
+}
+
+func (c *Compiler) newErrorTooManyElements(
+	expr *golox.ExpressionList,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseCompiler, golox.ErrorCodeTooManyElements,
+		expr.LeftBracket,
+		"too many elements in a list literal, the max is %d", maxElements,
+	)
+}
+
+func (c *Compiler) newErrorTooManyEntries(
+	expr *golox.ExpressionMap,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseCompiler, golox.ErrorCodeTooManyElements,
+		expr.LeftBrace,
+		"too many entries in a map literal, the max is %d", maxEntries,
+	)
+}
+
+func (c *Compiler) newErrorMissingImplementation(
+	n any,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseCompiler, golox.ErrorCodeMissingImplementation,
+		golox.Location{}, golox.Location{},
+		"missing implementation for type %T", n,
+	)
+}
+
+func (vm *VM) newErrorUndefinedVariable(
+	n node,
+) error {
+	identifier := identifierOf(n)
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeUndefinedVariable,
+		identifier,
+		"undefined variable '%s'", identifier.Lexeme,
+	)
+}
+
+func (vm *VM) newErrorOperandMustBe(
+	message string, // e.g. "a number"
+	n node,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidOperand,
+		operatorOf(n),
+		"operand must be %s", message,
+	)
+}
+
+func (vm *VM) newErrorOperandsMustBe(
+	message string, // e.g. "both numbers"
+	n node,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidOperand,
+		operatorOf(n),
+		"operands must be %s", message,
+	)
+}
+
+func (vm *VM) newErrorInvalidFunctionCallee(
+	callee golox.Expression,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidCallee,
+		callee.GetLocation(), golox.Location{},
+		"invalid function callee %s", callee,
+	)
+}
+
+func (vm *VM) newErrorFunctionArityMismatch(
+	expr golox.Expression,
+	want int,
+	got int,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeArityMismatch,
+		expr.GetLocation(), golox.Location{},
+		"function call %s expected %d arguments, got %d", expr, want, got,
+	)
+}
+
+func (vm *VM) newErrorVariadicFunctionArityMismatch(
+	expr golox.Expression,
+	atLeast int,
+	got int,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeArityMismatch,
+		expr.GetLocation(), golox.Location{},
+		"function call %s expected at least %d arguments, got %d", expr, atLeast, got,
+	)
+}
+
+func (vm *VM) newErrorNativeFunctionFailed(
+	expr golox.Expression,
+	fn *interpreter.NativeFunction,
+	err error,
+) error {
+	diag := golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeNativeFunctionFailed,
+		expr.GetLocation(), golox.Location{},
+		"%s: %s", fn.Name(), err,
+	)
+	diag.Err = err
+	return diag
+}
+
+func (vm *VM) newErrorStackOverflow(
+	expr golox.Expression,
+	depth int,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeStackOverflow,
+		expr.GetLocation(), golox.Location{},
+		"stack overflow at depth %d", depth,
+	).WithNote("the max call depth is %d", vm.maxCallDepth)
+}
+
+func (vm *VM) newErrorCancelled(
+	loc golox.Location,
+	err error,
+) error {
+	diag := golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeCancelled,
+		loc, golox.Location{},
+		"%s: %s", golox.ErrCancelled, err,
+	)
+	diag.Err = fmt.Errorf("%w: %w", golox.ErrCancelled, err)
+	return diag
+}
+
+func (vm *VM) newErrorBudgetExceeded(
+	loc golox.Location,
+) error {
+	diag := golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeBudgetExceeded,
+		loc, golox.Location{},
+		"%s", golox.ErrBudgetExceeded,
+	).WithNote("the max steps are %d", vm.maxSteps)
+	diag.Err = golox.ErrBudgetExceeded
+	return diag
+}
+
+func (vm *VM) newErrorAllocationLimit(
+	loc golox.Location,
+	what string,
+	limit int,
+) error {
+	diag := golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeAllocationLimit,
+		loc, golox.Location{},
+		"%s: more than %d %s", golox.ErrAllocationLimit, limit, what,
+	)
+	diag.Err = golox.ErrAllocationLimit
+	return diag
+}
+
+func (vm *VM) newErrorInvalidObjectInstance(
+	expr golox.Expression,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidInstance,
+		expr.GetLocation(), golox.Location{},
+		"invalid object instance %s", expr,
+	)
+}
+
+func (vm *VM) newErrorInvalidClass(
+	n node,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidSuperclass,
+		n.GetLocation(), golox.Location{},
+		"invalid class %s", n,
+	)
+}
+
+func (vm *VM) newErrorUndefinedProperty(
+	identifier golox.Token,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeUndefinedProperty,
+		identifier,
+		"undefined property '%s'", identifier.Lexeme,
+	)
+}
+
+func (vm *VM) newErrorIndexOutOfRange(
+	leftBracket golox.Token,
+	index float64,
+	length int,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeIndexOutOfRange,
+		leftBracket,
+		"index %s out of range for list of length %d", interpreter.Stringify(index), length,
+	)
+}
+
+func (vm *VM) newErrorInvalidIndex(
+	leftBracket golox.Token,
+	val any,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidIndex,
+		leftBracket,
+		"list index must be an integer, got %s", interpreter.Stringify(val),
+	)
+}
+
+func (vm *VM) newErrorInvalidKey(
+	key golox.Expression,
+	err error,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeInvalidKey,
+		key.GetLocation(), golox.Location{},
+		"%s", err,
+	)
+}
+
+func (vm *VM) newErrorUndefinedKey(
+	leftBracket golox.Token,
+	key any,
+) error {
+	return golox.NewDiagnosticAtToken(
+		golox.PhaseRuntime, golox.ErrorCodeUndefinedKey,
+		leftBracket,
+		"undefined key %s", interpreter.Stringify(key),
+	)
+}
+
+func (vm *VM) newErrorNotIndexable(
+	expr golox.Expression,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeNotIndexable,
+		expr.GetLocation(), golox.Location{},
+		"invalid indexed object %s, expected a list or a map", expr,
+	)
+}
+
+func (vm *VM) newErrorMissingImplementation(
+	op OpCode,
+) error {
+	return golox.NewDiagnostic(
+		golox.PhaseRuntime, golox.ErrorCodeMissingImplementation,
+		golox.Location{}, golox.Location{},
+		"missing implementation for %s", op,
+	)
+}
@@ -0,0 +1,69 @@
+package vm
+
+import golox "golox/internal"
+
+// Function is a compiled Lox function, or the top-level script of a run.
+type Function struct {
+	Name         string
+	ClassName    string // empty if the function is not a method
+	Location     golox.Location
+	Arity        int
+	UpvalueCount int
+	Chunk        Chunk
+}
+
+func (fn *Function) String() string {
+	if fn.Name == "" {
+		return "<script>"
+	}
+	return "<fn: " + fn.Name + ">"
+}
+
+// Closure is a Function with the variables it captured.
+type Closure struct {
+	Function *Function
+	Upvalues []*Upvalue
+	Globals  map[string]any // of the module defining the closure
+}
+
+func (c *Closure) String() string {
+	return c.Function.String()
+}
+
+// Upvalue is a variable captured by a closure. It refers to a slot of the VM
+// stack while the variable is in scope, and holds the value after.
+type Upvalue struct {
+	slot     int
+	isClosed bool
+	closed   any
+	next     *Upvalue // open upvalues are linked in decreasing slot order
+}
+
+type Class struct {
+	Name     string
+	Location golox.Location
+	Methods  map[string]*Closure // including the inherited methods
+}
+
+func (c *Class) String() string {
+	return "<class: " + c.Name + ">"
+}
+
+type Instance struct {
+	Class  *Class
+	Fields map[string]any
+}
+
+func (ins *Instance) String() string {
+	return "<instance of " + ins.Class.String() + ">"
+}
+
+// BoundMethod is a method bound to the instance it is accessed from.
+type BoundMethod struct {
+	Receiver *Instance
+	Method   *Closure
+}
+
+func (m *BoundMethod) String() string {
+	return m.Method.String()
+}
@@ -0,0 +1,122 @@
+package vm
+
+import "fmt"
+
+// OpCode is a bytecode instruction. Its operands follow it in the chunk, as u8
+// (1 byte) or u16 (2 bytes, big endian).
+type OpCode byte
+
+const (
+	OpConstant     OpCode = iota // u16 constant index
+	OpNil                        //
+	OpTrue                       //
+	OpFalse                      //
+	OpPop                        //
+	OpGetLocal                   // u8 slot
+	OpSetLocal                   // u8 slot
+	OpGetGlobal                  // u16 name constant index
+	OpDefineGlobal               // u16 name constant index
+	OpSetGlobal                  // u16 name constant index
+	OpGetUpvalue                 // u8 upvalue index
+	OpSetUpvalue                 // u8 upvalue index
+	OpGetProperty                // u16 name constant index
+	OpSetProperty                // u16 name constant index
+	OpGetSuper                   // u16 name constant index
+	OpEqual                      //
+	OpNotEqual                   //
+	OpGreater                    //
+	OpGreaterEqual               //
+	OpLess                       //
+	OpLessEqual                  //
+	OpAdd                        //
+	OpSubtract                   //
+	OpMultiply                   //
+	OpDivide                     //
+	OpNot                        //
+	OpNegate                     //
+	OpPrint                      //
+	OpJump                       // u16 forward offset
+	OpJumpIfFalse                // u16 forward offset, keeps the condition
+	OpLoop                       // u16 backward offset
+	OpCall                       // u8 argument count
+	OpInvoke                     // u16 name constant index, u8 argument count
+	OpSuperInvoke                // u16 name constant index, u8 argument count
+	OpClosure                    // u16 function constant index, (u8 is local, u8 index) per upvalue
+	OpCloseUpvalue               //
+	OpReturn                     //
+	OpClass                      // u16 name constant index
+	OpInherit                    //
+	OpMethod                     // u16 name constant index
+	OpList                       // u16 element count
+	OpMap                        // u16 entry count
+	OpGetIndex                   //
+	OpSetIndex                   //
+	OpTry                        // u16 forward offset to the catch handler
+	OpTryFinally                 // u16 forward offset to the finally handler
+	OpPopTry                     //
+	OpThrow                      //
+	OpRethrow                    //
+	OpImport                     //
+	OpGetExport                  // u16 name constant index
+)
+
+var opCodeNames = [...]string{
+	OpConstant:     "OP_CONSTANT",
+	OpNil:          "OP_NIL",
+	OpTrue:         "OP_TRUE",
+	OpFalse:        "OP_FALSE",
+	OpPop:          "OP_POP",
+	OpGetLocal:     "OP_GET_LOCAL",
+	OpSetLocal:     "OP_SET_LOCAL",
+	OpGetGlobal:    "OP_GET_GLOBAL",
+	OpDefineGlobal: "OP_DEFINE_GLOBAL",
+	OpSetGlobal:    "OP_SET_GLOBAL",
+	OpGetUpvalue:   "OP_GET_UPVALUE",
+	OpSetUpvalue:   "OP_SET_UPVALUE",
+	OpGetProperty:  "OP_GET_PROPERTY",
+	OpSetProperty:  "OP_SET_PROPERTY",
+	OpGetSuper:     "OP_GET_SUPER",
+	OpEqual:        "OP_EQUAL",
+	OpNotEqual:     "OP_NOT_EQUAL",
+	OpGreater:      "OP_GREATER",
+	OpGreaterEqual: "OP_GREATER_EQUAL",
+	OpLess:         "OP_LESS",
+	OpLessEqual:    "OP_LESS_EQUAL",
+	OpAdd:          "OP_ADD",
+	OpSubtract:     "OP_SUBTRACT",
+	OpMultiply:     "OP_MULTIPLY",
+	OpDivide:       "OP_DIVIDE",
+	OpNot:          "OP_NOT",
+	OpNegate:       "OP_NEGATE",
+	OpPrint:        "OP_PRINT",
+	OpJump:         "OP_JUMP",
+	OpJumpIfFalse:  "OP_JUMP_IF_FALSE",
+	OpLoop:         "OP_LOOP",
+	OpCall:         "OP_CALL",
+	OpInvoke:       "OP_INVOKE",
+	OpSuperInvoke:  "OP_SUPER_INVOKE",
+	OpClosure:      "OP_CLOSURE",
+	OpCloseUpvalue: "OP_CLOSE_UPVALUE",
+	OpReturn:       "OP_RETURN",
+	OpClass:        "OP_CLASS",
+	OpInherit:      "OP_INHERIT",
+	OpMethod:       "OP_METHOD",
+	OpList:         "OP_LIST",
+	OpMap:          "OP_MAP",
+	OpGetIndex:     "OP_GET_INDEX",
+	OpSetIndex:     "OP_SET_INDEX",
+	OpTry:          "OP_TRY",
+	OpTryFinally:   "OP_TRY_FINALLY",
+	OpPopTry:       "OP_POP_TRY",
+	OpThrow:        "OP_THROW",
+	OpRethrow:      "OP_RETHROW",
+	OpImport:       "OP_IMPORT",
+	OpGetExport:    "OP_GET_EXPORT",
+}
+
+func (op OpCode) String() string {
+	if int(op) < len(opCodeNames) {
+		return opCodeNames[op]
+	}
+	return fmt.Sprintf("OpCode(%d)", int(op))
+}
@@ -0,0 +1,913 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	golox "golox/internal"
+	"golox/internal/interpreter"
+	"golox/internal/interpreter/builtins"
+	"io"
+	"math"
+)
+
+// frame is a call of a closure in progress.
+type frame struct {
+	closure  *Closure
+	ip       int            // index of the next instruction in the chunk
+	base     int            // index of slot 0 in the stack
+	callSite golox.Location // zero for the script
+}
+
+// handler is the handler of a try statement in progress.
+type handler struct {
+	frameCount int  // of the call running the try statement
+	sp         int  // to restore when unwinding
+	ip         int  // of the handler in the chunk of the call
+	isFinally  bool // the handler runs a finally clause, else a catch clause
+}
+
+// pendingError is an error caught by a finally handler, which rethrows it.
+type pendingError struct {
+	err error
+}
+
+// VM executes the bytecode compiled by Compiler on a value stack. It reports
+// the same runtime errors as the tree-walk interpreter.
+type VM struct {
+	// configs:
+	isDebug      bool
+	stdout       io.Writer // for program outputs
+	logger       *golox.Logger
+	maxCallDepth int
+	maxSteps     int
+	limits       golox.AllocationLimits
+	compiler     *Compiler
+	importer     interpreter.Importer // nil if imports are not supported
+
+	// states:
+	globals      map[string]any
+	stack        []any
+	sp           int // index of the next free slot in stack
+	frames       []frame
+	handlers     []handler // of the try statements in progress, innermost last
+	openUpvalues *Upvalue  // the open upvalue of the highest slot
+	runState     *runState // shared with the VMs of the modules it imports
+	baseDepth    int       // of the calls of the VMs importing the module run by vm
+}
+
+// runState is the state of the current run. The top level of an imported
+// module runs in its own VM with the run state of the importing run, so that
+// its calls, steps and allocations count in that run and are cancelled with it.
+// The functions of a module run in the VM calling them.
+type runState struct {
+	ctx    context.Context
+	steps  int
+	allocs allocations
+}
+
+func (run *runState) Context() context.Context {
+	return run.ctx
+}
+
+// importingRun is the run of an import statement, with the call depth of the
+// statement.
+type importingRun struct {
+	*runState
+	depth int
+}
+
+func (vm *VM) push(val any) {
+	if vm.sp == len(vm.stack) {
+		vm.stack = append(vm.stack, nil)
+		vm.stack = vm.stack[:cap(vm.stack)]
+	}
+	vm.stack[vm.sp] = val
+	vm.sp++
+}
+
+func (vm *VM) pop() any {
+	vm.sp--
+	return vm.stack[vm.sp]
+}
+
+func (vm *VM) peek(distance int) any {
+	return vm.stack[vm.sp-1-distance]
+}
+
+func isValueTruthy(val any) bool {
+	if val == nil {
+		return false
+	} else if val, ok := val.(bool); ok {
+		return val
+	} else {
+		return true // strings and numbers are always true
+	}
+}
+
+// numberOperands returns the two operands on top of the stack if they are both
+// numbers.
+func (vm *VM) numberOperands() (float64, float64, bool) {
+	lhs, ok1 := vm.stack[vm.sp-2].(float64)
+	rhs, ok2 := vm.stack[vm.sp-1].(float64)
+	return lhs, rhs, ok1 && ok2
+}
+
+// captureUpvalue returns the upvalue of slot, shared by all the closures
+// capturing it.
+func (vm *VM) captureUpvalue(slot int) *Upvalue {
+	var prev *Upvalue
+	upvalue := vm.openUpvalues
+	for upvalue != nil && upvalue.slot > slot {
+		prev = upvalue
+		upvalue = upvalue.next
+	}
+	if upvalue != nil && upvalue.slot == slot {
+		return upvalue
+	}
+
+	created := &Upvalue{slot: slot, isClosed: false, closed: nil, next: upvalue}
+	if prev == nil {
+		vm.openUpvalues = created
+	} else {
+		prev.next = created
+	}
+	return created
+}
+
+// closeUpvalues moves the values of the slots from last out of the stack, as
+// they go out of scope.
+func (vm *VM) closeUpvalues(last int) {
+	for vm.openUpvalues != nil && vm.openUpvalues.slot >= last {
+		upvalue := vm.openUpvalues
+		upvalue.closed = vm.stack[upvalue.slot]
+		upvalue.isClosed = true
+		vm.openUpvalues = upvalue.next
+	}
+}
+
+func (vm *VM) getUpvalue(upvalue *Upvalue) any {
+	if upvalue.isClosed {
+		return upvalue.closed
+	}
+	return vm.stack[upvalue.slot]
+}
+
+func (vm *VM) setUpvalue(upvalue *Upvalue, val any) {
+	if upvalue.isClosed {
+		upvalue.closed = val
+	} else {
+		vm.stack[upvalue.slot] = val
+	}
+}
+
+// callValue calls callee with the argCount arguments on top of the stack.
+func (vm *VM) callValue(callee any, argCount int, expr *golox.ExpressionCall) error {
+	switch callee := callee.(type) {
+	case *Closure:
+		return vm.callClosure(callee, argCount, expr)
+
+	case *BoundMethod:
+		vm.stack[vm.sp-argCount-1] = callee.Receiver
+		return vm.callClosure(callee.Method, argCount, expr)
+
+	case *Class:
+		init, hasInit := callee.Methods["init"]
+		if hasInit && argCount != init.Function.Arity {
+			return vm.newErrorFunctionArityMismatch(expr, init.Function.Arity, argCount)
+		} else if !hasInit && argCount != 0 {
+			return vm.newErrorFunctionArityMismatch(expr, 0, argCount)
+		} else if err := vm.checkCall(expr); err != nil {
+			return err
+		}
+
+		initFrame := golox.StackFrame{FunctionName: "init", ClassName: callee.Name, CallSite: expr.GetLocation()}
+		if err := vm.allocateInstance(callee); err != nil {
+			return vm.withStackTrace(err, initFrame)
+		}
+		vm.stack[vm.sp-argCount-1] = &Instance{Class: callee, Fields: map[string]any{}}
+		if hasInit {
+			return vm.pushFrame(init, argCount, expr)
+		}
+		return nil
+
+	case interpreter.LoxCallable:
+		if fn, ok := callee.(*interpreter.NativeFunction); ok && fn.IsVariadic() {
+			if argCount < fn.Arity() {
+				return vm.newErrorVariadicFunctionArityMismatch(expr, fn.Arity(), argCount)
+			}
+		} else if argCount != callee.Arity() {
+			return vm.newErrorFunctionArityMismatch(expr, callee.Arity(), argCount)
+		}
+		if err := vm.checkCall(expr); err != nil {
+			return err
+		}
+
+		args := make([]any, argCount)
+		copy(args, vm.stack[vm.sp-argCount:vm.sp])
+		val, err := callee.Call(args)
+		if err != nil {
+			nativeFrame := golox.StackFrame{FunctionName: callee.String(), ClassName: "", CallSite: expr.GetLocation()}
+			if fn, ok := callee.(*interpreter.NativeFunction); ok {
+				nativeFrame.FunctionName = fn.Name()
+				// the allocations of native methods are already runtime errors
+				if !errors.Is(err, golox.ErrAllocationLimit) {
+					err = vm.newErrorNativeFunctionFailed(expr, fn, err)
+				}
+			}
+			return vm.withStackTrace(err, nativeFrame)
+		}
+		vm.sp -= argCount + 1
+		vm.push(val)
+		return nil
+
+	default:
+		return vm.newErrorInvalidFunctionCallee(expr.Callee)
+	}
+}
+
+func (vm *VM) callClosure(closure *Closure, argCount int, expr *golox.ExpressionCall) error {
+	if argCount != closure.Function.Arity {
+		return vm.newErrorFunctionArityMismatch(expr, closure.Function.Arity, argCount)
+	} else if err := vm.checkCall(expr); err != nil {
+		return err
+	} else {
+		return vm.pushFrame(closure, argCount, expr)
+	}
+}
+
+// checkCall counts a call as a step. The call depth is limited, so that
+// unbounded recursion is a runtime error instead of a growing stack.
+func (vm *VM) checkCall(expr *golox.ExpressionCall) error {
+	if err := vm.step(expr.GetLocation()); err != nil {
+		return err
+	} else if depth := vm.baseDepth + len(vm.frames) - 1; depth >= vm.maxCallDepth {
+		return vm.newErrorStackOverflow(expr, depth)
+	}
+	return nil
+}
+
+func (vm *VM) pushFrame(closure *Closure, argCount int, expr *golox.ExpressionCall) error {
+	if err := vm.allocateScope(closure.Function.Location); err != nil {
+		return err
+	}
+	vm.frames = append(vm.frames, frame{
+		closure:  closure,
+		ip:       0,
+		base:     vm.sp - argCount - 1,
+		callSite: expr.GetLocation(),
+	})
+	return nil
+}
+
+// invoke calls the method name of the instance below the argCount arguments on
+// top of the stack, without creating a bound method.
+func (vm *VM) invoke(name string, argCount int, expr *golox.ExpressionCall) error {
+	get := expr.Callee.(*golox.ExpressionGet)
+	if obj, ok := vm.peek(argCount).(nativeObject); ok {
+		if method, err := obj.Method(get.Identifier, vm.elementAllocator(get.Identifier.Location)); err != nil {
+			return err
+		} else {
+			vm.stack[vm.sp-argCount-1] = method
+			return vm.callValue(method, argCount, expr)
+		}
+	} else if obj, ok := vm.peek(argCount).(propertyObject); ok {
+		if val, err := obj.Get(get.Identifier); err != nil {
+			return err
+		} else {
+			vm.stack[vm.sp-argCount-1] = val
+			return vm.callValue(val, argCount, expr)
+		}
+	} else if ins, ok := vm.peek(argCount).(*Instance); !ok {
+		return vm.newErrorInvalidObjectInstance(get.Object)
+	} else if val, ok := ins.Fields[name]; ok {
+		vm.stack[vm.sp-argCount-1] = val
+		return vm.callValue(val, argCount, expr)
+	} else if method, ok := ins.Class.Methods[name]; ok {
+		return vm.callClosure(method, argCount, expr)
+	} else {
+		return vm.newErrorUndefinedProperty(get.Identifier)
+	}
+}
+
+// nativeObject is a value with native methods, e.g. a list.
+type nativeObject interface {
+	Method(identifier golox.Token, allocate interpreter.Allocator) (*interpreter.NativeFunction, error)
+}
+
+// propertyObject is a value with read-only native properties, e.g. a module.
+type propertyObject interface {
+	Get(identifier golox.Token) (any, error)
+}
+
+// listIndex returns the position in the list of the index val, or an error if
+// val is not an integer in the range of list.
+func (vm *VM) listIndex(list *interpreter.LoxList, val any, leftBracket golox.Token) (int, error) {
+	if index, ok := val.(float64); !ok || index != math.Trunc(index) {
+		return 0, vm.newErrorInvalidIndex(leftBracket, val)
+	} else if i, ok := list.Index(index); !ok {
+		return 0, vm.newErrorIndexOutOfRange(leftBracket, index, len(list.Elements))
+	} else {
+		return i, nil
+	}
+}
+
+// getIndex returns the element of the list or the map obj at index.
+func (vm *VM) getIndex(expr *golox.ExpressionIndex, obj any, index any) (any, error) {
+	switch obj := obj.(type) {
+	case *interpreter.LoxList:
+		if i, err := vm.listIndex(obj, index, expr.LeftBracket); err != nil {
+			return nil, err
+		} else {
+			return obj.Elements[i], nil
+		}
+	case *interpreter.LoxMap:
+		if err := interpreter.CheckMapKey(index); err != nil {
+			return nil, vm.newErrorInvalidKey(expr.Index, err)
+		} else if val, ok := obj.Get(index); !ok {
+			return nil, vm.newErrorUndefinedKey(expr.LeftBracket, index)
+		} else {
+			return val, nil
+		}
+	default:
+		return nil, vm.newErrorNotIndexable(expr.Object)
+	}
+}
+
+// setIndex sets the element of the list or the map obj at index to val.
+func (vm *VM) setIndex(expr *golox.ExpressionIndexSet, obj any, index any, val any) error {
+	switch obj := obj.(type) {
+	case *interpreter.LoxList:
+		if i, err := vm.listIndex(obj, index, expr.LeftBracket); err != nil {
+			return err
+		} else {
+			obj.Elements[i] = val
+			return nil
+		}
+	case *interpreter.LoxMap:
+		if err := interpreter.CheckMapKey(index); err != nil {
+			return vm.newErrorInvalidKey(expr.Index, err)
+		}
+		if _, ok := obj.Get(index); !ok {
+			if err := vm.allocateElements(1, expr.LeftBracket.Location); err != nil {
+				return err
+			}
+		}
+		obj.Set(index, val)
+		return nil
+	default:
+		return vm.newErrorNotIndexable(expr.Object)
+	}
+}
+
+// StackTrace returns the current call stack, with the most recent call first.
+func (vm *VM) StackTrace() []golox.StackFrame {
+	trace := make([]golox.StackFrame, 0, len(vm.frames))
+	for i := len(vm.frames) - 1; i > 0; i-- {
+		fn := vm.frames[i].closure.Function
+		trace = append(trace, golox.StackFrame{
+			FunctionName: fn.Name,
+			ClassName:    fn.ClassName,
+			CallSite:     vm.frames[i].callSite,
+		})
+	}
+	return trace
+}
+
+// withStackTrace attaches the current call stack to err, below the frames of
+// the calls not pushed to the VM, e.g. native functions.
+func (vm *VM) withStackTrace(err error, frames ...golox.StackFrame) error {
+	var diag *golox.Diagnostic
+	if errors.As(err, &diag) && diag.Phase == golox.PhaseRuntime && diag.StackTrace == nil {
+		diag.StackTrace = append(frames, vm.StackTrace()...)
+	}
+	return err
+}
+
+// fail attaches the stack trace to err and unwinds all the calls.
+func (vm *VM) fail(err error) (any, error) {
+	err = vm.withStackTrace(err)
+	vm.sp = 0
+	vm.frames = vm.frames[:0]
+	vm.handlers = vm.handlers[:0]
+	vm.openUpvalues = nil
+	return nil, err
+}
+
+// catch unwinds the calls to the innermost handler and jumps to it, if err can
+// be caught. A catch handler gets the caught value on top of the stack, and a
+// finally handler gets the pending error to rethrow.
+func (vm *VM) catch(err error) bool {
+	if len(vm.handlers) == 0 || !interpreter.IsCatchable(err) {
+		return false
+	}
+	err = vm.withStackTrace(err)
+	h := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	vm.closeUpvalues(h.sp)
+	vm.frames = vm.frames[:h.frameCount]
+	vm.sp = h.sp
+	if h.isFinally {
+		vm.push(&pendingError{err: err})
+	} else {
+		vm.push(interpreter.CaughtValue(err))
+	}
+	vm.frames[h.frameCount-1].ip = h.ip
+	return true
+}
+
+// run executes the frames until the script returns, catching the errors of
+// try statements.
+func (vm *VM) run() (any, error) {
+	for {
+		if result, err := vm.execute(); err == nil {
+			return result, nil
+		} else if !vm.catch(err) {
+			return vm.fail(err)
+		}
+	}
+}
+
+// execute executes the instructions of the current frame until the script
+// returns or an error is raised.
+func (vm *VM) execute() (any, error) {
+	fr := &vm.frames[len(vm.frames)-1]
+	chunk := &fr.closure.Function.Chunk
+
+	for {
+		start := fr.ip
+		vm.logInstruction(chunk, start)
+		op := OpCode(chunk.Code[start])
+		fr.ip++
+
+		switch op {
+		case OpConstant:
+			vm.push(chunk.Constants[chunk.readU16(fr.ip)])
+			fr.ip += 2
+
+		case OpNil:
+			vm.push(nil)
+
+		case OpTrue:
+			vm.push(true)
+
+		case OpFalse:
+			vm.push(false)
+
+		case OpPop:
+			vm.sp--
+
+		case OpGetLocal:
+			vm.push(vm.stack[fr.base+int(chunk.Code[fr.ip])])
+			fr.ip++
+
+		case OpSetLocal:
+			vm.stack[fr.base+int(chunk.Code[fr.ip])] = vm.peek(0)
+			fr.ip++
+
+		case OpGetGlobal:
+			name := chunk.Constants[chunk.readU16(fr.ip)].(string)
+			fr.ip += 2
+			if val, ok := fr.closure.Globals[name]; !ok {
+				return nil, vm.newErrorUndefinedVariable(chunk.Nodes[start])
+			} else {
+				vm.push(val)
+			}
+
+		case OpDefineGlobal:
+			name := chunk.Constants[chunk.readU16(fr.ip)].(string)
+			fr.ip += 2
+			fr.closure.Globals[name] = vm.pop()
+
+		case OpSetGlobal:
+			name := chunk.Constants[chunk.readU16(fr.ip)].(string)
+			fr.ip += 2
+			if _, ok := fr.closure.Globals[name]; !ok {
+				return nil, vm.newErrorUndefinedVariable(chunk.Nodes[start])
+			}
+			fr.closure.Globals[name] = vm.peek(0)
+
+		case OpGetUpvalue:
+			vm.push(vm.getUpvalue(fr.closure.Upvalues[chunk.Code[fr.ip]]))
+			fr.ip++
+
+		case OpSetUpvalue:
+			vm.setUpvalue(fr.closure.Upvalues[chunk.Code[fr.ip]], vm.peek(0))
+			fr.ip++
+
+		case OpGetProperty:
+			name := chunk.Constants[chunk.readU16(fr.ip)].(string)
+			fr.ip += 2
+			get := chunk.Nodes[start].(*golox.ExpressionGet)
+			if obj, ok := vm.peek(0).(nativeObject); ok {
+				if method, err := obj.Method(get.Identifier, vm.elementAllocator(get.Identifier.Location)); err != nil {
+					return nil, err
+				} else {
+					vm.stack[vm.sp-1] = method
+				}
+			} else if obj, ok := vm.peek(0).(propertyObject); ok {
+				if val, err := obj.Get(get.Identifier); err != nil {
+					return nil, err
+				} else {
+					vm.stack[vm.sp-1] = val
+				}
+			} else if ins, ok := vm.peek(0).(*Instance); !ok {
+				return nil, vm.newErrorInvalidObjectInstance(get.Object)
+			} else if val, ok := ins.Fields[name]; ok {
+				vm.stack[vm.sp-1] = val
+			} else if method, ok := ins.Class.Methods[name]; ok {
+				vm.stack[vm.sp-1] = &BoundMethod{Receiver: ins, Method: method}
+			} else {
+				return nil, vm.newErrorUndefinedProperty(get.Identifier)
+			}
+
+		case OpSetProperty:
+			name := chunk.Constants[chunk.readU16(fr.ip)].(string)
+			fr.ip += 2
+			set := chunk.Nodes[start].(*golox.ExpressionSet)
+			if ins, ok := vm.peek(1).(*Instance); !ok {
+				return nil, vm.newErrorInvalidObjectInstance(set.Object)
+			} else if err := vm.allocateField(ins, set.Identifier); err != nil {
+				return nil, err
+			} else {
+				val := vm.pop()
+				ins.Fields[name] = val
+				vm.stack[vm.sp-1] = val
+			}
+
+		case OpGetSuper:
+			name := chunk.Constants[chunk.readU16(fr.ip)].(string)
+			fr.ip += 2
+			superclass := vm.pop().(*Class)
+			if method, ok := superclass.Methods[name]; !ok {
+				return nil, vm.newErrorUndefinedProperty(chunk.Nodes[start].(*golox.ExpressionSuper).Method)
+			} else {
+				vm.stack[vm.sp-1] = &BoundMethod{Receiver: vm.peek(0).(*Instance), Method: method}
+			}
+
+		case OpEqual:
+			vm.stack[vm.sp-2] = vm.stack[vm.sp-2] == vm.stack[vm.sp-1]
+			vm.sp--
+
+		case OpNotEqual:
+			vm.stack[vm.sp-2] = vm.stack[vm.sp-2] != vm.stack[vm.sp-1]
+			vm.sp--
+
+		case OpGreater, OpGreaterEqual, OpLess, OpLessEqual, OpSubtract, OpMultiply, OpDivide:
+			lhs, rhs, ok := vm.numberOperands()
+			if !ok {
+				return nil, vm.newErrorOperandsMustBe("both numbers", chunk.Nodes[start])
+			}
+			vm.sp--
+			switch op {
+			case OpGreater:
+				vm.stack[vm.sp-1] = lhs > rhs
+			case OpGreaterEqual:
+				vm.stack[vm.sp-1] = lhs >= rhs
+			case OpLess:
+				vm.stack[vm.sp-1] = lhs < rhs
+			case OpLessEqual:
+				vm.stack[vm.sp-1] = lhs <= rhs
+			case OpSubtract:
+				vm.stack[vm.sp-1] = lhs - rhs
+			case OpMultiply:
+				vm.stack[vm.sp-1] = lhs * rhs
+			case OpDivide:
+				vm.stack[vm.sp-1] = lhs / rhs
+			}
+
+		case OpAdd:
+			if lhs, rhs, ok := vm.numberOperands(); ok {
+				vm.sp--
+				vm.stack[vm.sp-1] = lhs + rhs
+			} else if lhs, ok := vm.stack[vm.sp-2].(string); !ok {
+				return nil, vm.newErrorOperandsMustBe("both numbers or both strings", chunk.Nodes[start])
+			} else if rhs, ok := vm.stack[vm.sp-1].(string); !ok {
+				return nil, vm.newErrorOperandsMustBe("both numbers or both strings", chunk.Nodes[start])
+			} else if err := vm.allocateString(len(lhs)+len(rhs), chunk.Nodes[start]); err != nil {
+				return nil, err
+			} else {
+				vm.sp--
+				vm.stack[vm.sp-1] = lhs + rhs
+			}
+
+		case OpNot:
+			vm.stack[vm.sp-1] = !isValueTruthy(vm.stack[vm.sp-1])
+
+		case OpNegate:
+			if val, ok := vm.stack[vm.sp-1].(float64); !ok {
+				return nil, vm.newErrorOperandMustBe("a number", chunk.Nodes[start])
+			} else {
+				vm.stack[vm.sp-1] = -val
+			}
+
+		case OpPrint:
+			fmt.Fprintln(vm.stdout, interpreter.Stringify(vm.pop()))
+
+		case OpJump:
+			fr.ip += 2 + chunk.readU16(fr.ip)
+
+		case OpJumpIfFalse:
+			if !isValueTruthy(vm.peek(0)) {
+				fr.ip += chunk.readU16(fr.ip)
+			}
+			fr.ip += 2
+
+		case OpLoop:
+			fr.ip += 2 - chunk.readU16(fr.ip)
+			if err := vm.step(chunk.Nodes[start].GetLocation()); err != nil {
+				return nil, err
+			}
+
+		case OpCall:
+			argCount := int(chunk.Code[fr.ip])
+			fr.ip++
+			if err := vm.callValue(vm.peek(argCount), argCount, chunk.Nodes[start].(*golox.ExpressionCall)); err != nil {
+				return nil, err
+			}
+			fr = &vm.frames[len(vm.frames)-1]
+			chunk = &fr.closure.Function.Chunk
+
+		case OpInvoke:
+			name := chunk.Constants[chunk.readU16(fr.ip)].(string)
+			argCount := int(chunk.Code[fr.ip+2])
+			fr.ip += 3
+			if err := vm.invoke(name, argCount, chunk.Nodes[start].(*golox.ExpressionCall)); err != nil {
+				return nil, err
+			}
+			fr = &vm.frames[len(vm.frames)-1]
+			chunk = &fr.closure.Function.Chunk
+
+		case OpSuperInvoke:
+			name := chunk.Constants[chunk.readU16(fr.ip)].(string)
+			argCount := int(chunk.Code[fr.ip+2])
+			fr.ip += 3
+			expr := chunk.Nodes[start].(*golox.ExpressionCall)
+			superclass := vm.pop().(*Class)
+			if method, ok := superclass.Methods[name]; !ok {
+				return nil, vm.newErrorUndefinedProperty(expr.Callee.(*golox.ExpressionSuper).Method)
+			} else if err := vm.callClosure(method, argCount, expr); err != nil {
+				return nil, err
+			}
+			fr = &vm.frames[len(vm.frames)-1]
+			chunk = &fr.closure.Function.Chunk
+
+		case OpClosure:
+			fn := chunk.Constants[chunk.readU16(fr.ip)].(*Function)
+			fr.ip += 2
+			closure := &Closure{Function: fn, Upvalues: make([]*Upvalue, fn.UpvalueCount), Globals: fr.closure.Globals}
+			for i := range closure.Upvalues {
+				isLocal, index := chunk.Code[fr.ip], int(chunk.Code[fr.ip+1])
+				fr.ip += 2
+				if isLocal == 1 {
+					closure.Upvalues[i] = vm.captureUpvalue(fr.base + index)
+				} else {
+					closure.Upvalues[i] = fr.closure.Upvalues[index]
+				}
+			}
+			vm.push(closure)
+
+		case OpCloseUpvalue:
+			vm.closeUpvalues(vm.sp - 1)
+			vm.sp--
+
+		case OpReturn:
+			result := vm.pop()
+			vm.closeUpvalues(fr.base)
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			if len(vm.frames) == 0 {
+				vm.sp = 0
+				return result, nil
+			}
+			vm.sp = fr.base
+			vm.push(result)
+			fr = &vm.frames[len(vm.frames)-1]
+			chunk = &fr.closure.Function.Chunk
+
+		case OpClass:
+			name := chunk.Constants[chunk.readU16(fr.ip)].(string)
+			fr.ip += 2
+			vm.push(&Class{
+				Name:     name,
+				Location: chunk.Nodes[start].(*golox.StatementClass).Identifier.Location,
+				Methods:  map[string]*Closure{},
+			})
+
+		case OpInherit:
+			if superclass, ok := vm.peek(1).(*Class); !ok {
+				return nil, vm.newErrorInvalidClass(chunk.Nodes[start])
+			} else {
+				subclass := vm.pop().(*Class)
+				for name, method := range superclass.Methods {
+					subclass.Methods[name] = method
+				}
+			}
+
+		case OpMethod:
+			name := chunk.Constants[chunk.readU16(fr.ip)].(string)
+			fr.ip += 2
+			method := vm.pop().(*Closure)
+			vm.peek(0).(*Class).Methods[name] = method
+
+		case OpList:
+			count := chunk.readU16(fr.ip)
+			fr.ip += 2
+			expr := chunk.Nodes[start].(*golox.ExpressionList)
+			if err := vm.allocateElements(count, expr.LeftBracket.Location); err != nil {
+				return nil, err
+			}
+			elements := make([]any, count)
+			copy(elements, vm.stack[vm.sp-count:vm.sp])
+			vm.sp -= count
+			vm.push(&interpreter.LoxList{Elements: elements})
+
+		case OpMap:
+			count := chunk.readU16(fr.ip)
+			fr.ip += 2
+			expr := chunk.Nodes[start].(*golox.ExpressionMap)
+			if err := vm.allocateElements(count, expr.LeftBrace.Location); err != nil {
+				return nil, err
+			}
+			entries := vm.stack[vm.sp-2*count : vm.sp]
+			m := interpreter.NewLoxMap()
+			for i := 0; i < count; i++ {
+				if err := interpreter.CheckMapKey(entries[2*i]); err != nil {
+					return nil, vm.newErrorInvalidKey(expr.Keys[i], err)
+				}
+				m.Set(entries[2*i], entries[2*i+1])
+			}
+			vm.sp -= 2 * count
+			vm.push(m)
+
+		case OpGetIndex:
+			expr := chunk.Nodes[start].(*golox.ExpressionIndex)
+			if val, err := vm.getIndex(expr, vm.peek(1), vm.peek(0)); err != nil {
+				return nil, err
+			} else {
+				vm.sp--
+				vm.stack[vm.sp-1] = val
+			}
+
+		case OpSetIndex:
+			expr := chunk.Nodes[start].(*golox.ExpressionIndexSet)
+			val := vm.peek(0)
+			if err := vm.setIndex(expr, vm.peek(2), vm.peek(1), val); err != nil {
+				return nil, err
+			} else {
+				vm.sp -= 2
+				vm.stack[vm.sp-1] = val
+			}
+
+		case OpTry, OpTryFinally:
+			vm.handlers = append(vm.handlers, handler{
+				frameCount: len(vm.frames),
+				sp:         vm.sp,
+				ip:         fr.ip + 2 + chunk.readU16(fr.ip),
+				isFinally:  op == OpTryFinally,
+			})
+			fr.ip += 2
+
+		case OpPopTry:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+		case OpImport:
+			run := &importingRun{runState: vm.runState, depth: vm.baseDepth + len(vm.frames) - 1}
+			if module, err := interpreter.ImportModule(run, vm.importer, chunk.Nodes[start].(*golox.StatementImport)); err != nil {
+				return nil, err
+			} else {
+				vm.push(module)
+			}
+
+		case OpGetExport:
+			name := chunk.Constants[chunk.readU16(fr.ip)].(string)
+			fr.ip += 2
+			identifier := importedName(chunk.Nodes[start].(*golox.StatementImport), name)
+			if val, err := vm.peek(0).(*interpreter.LoxModule).Get(identifier); err != nil {
+				return nil, err
+			} else {
+				vm.stack[vm.sp-1] = val
+			}
+
+		case OpThrow:
+			return nil, interpreter.NewThrowError(chunk.Nodes[start].(*golox.StatementThrow).ThrowToken, vm.pop())
+
+		case OpRethrow:
+			return nil, vm.pop().(*pendingError).err
+
+		default:
+			return nil, vm.newErrorMissingImplementation(op)
+		}
+	}
+}
+
+// InterpretStatements compiles and executes stmts in the global scope. If the
+// last statement is an expression statement, its value is returned.
+//
+// The run is aborted with a runtime error wrapping golox.ErrCancelled when ctx
+// is done, or golox.ErrBudgetExceeded when it takes more than the max steps.
+func (vm *VM) InterpretStatements(
+	ctx context.Context,
+	stmts []golox.Statement,
+) (
+	any,
+	error,
+) {
+	fn, err := vm.compiler.CompileStatements(stmts)
+	if err != nil {
+		return nil, err
+	}
+
+	vm.runState.ctx = ctx
+	vm.runState.steps = 0
+	vm.runState.allocs = allocations{}
+	vm.baseDepth = 0
+	return vm.runScript(fn)
+}
+
+// ImportStatements compiles and executes stmts, the top level of an imported
+// module, in the global scope and in run, the run importing the module.
+func (vm *VM) ImportStatements(run interpreter.Run, stmts []golox.Statement) error {
+	fn, err := vm.compiler.CompileStatements(stmts)
+	if err != nil {
+		return err
+	}
+
+	importing := run.(*importingRun)
+	vm.runState = importing.runState
+	vm.baseDepth = importing.depth
+	_, err = vm.runScript(fn)
+	return err
+}
+
+// runScript executes fn, the function of a script, from an empty stack.
+func (vm *VM) runScript(fn *Function) (any, error) {
+	vm.sp = 0
+	vm.frames = vm.frames[:0]
+	vm.handlers = vm.handlers[:0]
+	vm.openUpvalues = nil
+
+	script := &Closure{Function: fn, Upvalues: nil, Globals: vm.globals}
+	vm.push(script)
+	vm.frames = append(vm.frames, frame{
+		closure:  script,
+		ip:       0,
+		base:     0,
+		callSite: golox.Location{},
+	})
+	return vm.run()
+}
+
+func (vm *VM) GetGlobal(name string) (any, bool) {
+	val, ok := vm.globals[name]
+	return val, ok
+}
+
+// SetGlobal defines or overwrites a global variable.
+func (vm *VM) SetGlobal(name string, val any) {
+	vm.globals[name] = val
+}
+
+// Globals returns the global variables of the session, by name.
+func (vm *VM) Globals() map[string]any {
+	return vm.globals
+}
+
+// SetDebug enables or disables the debug logs of the compiler and the VM.
+func (vm *VM) SetDebug(isDebug bool) {
+	vm.isDebug = isDebug
+	vm.logger.SetDebug(isDebug)
+	vm.compiler.isDebug = isDebug
+	vm.compiler.logger.SetDebug(isDebug)
+}
+
+// SetImporter sets the importer of the modules of import statements.
+func (vm *VM) SetImporter(importer interpreter.Importer) {
+	vm.importer = importer
+}
+
+func NewVM(
+	config golox.Config,
+) *VM {
+	return &VM{
+		isDebug:      config.IsDebug,
+		stdout:       config.StdoutWriter(),
+		logger:       golox.NewLogger(config.IsDebug, config.StderrWriter()),
+		maxCallDepth: config.CallDepthLimit(),
+		maxSteps:     config.MaxSteps,
+		limits:       config.AllocationLimits,
+		compiler:     NewCompiler(config),
+		importer:     nil,
+		globals:      builtins.Globals(config.ClockSource()),
+		stack:        make([]any, 256),
+		sp:           0,
+		frames:       make([]frame, 0, 64),
+		handlers:     nil,
+		openUpvalues: nil,
+		runState: &runState{
+			ctx:    context.Background(),
+			steps:  0,
+			allocs: allocations{},
+		},
+		baseDepth: 0,
+	}
+}
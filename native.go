@@ -0,0 +1,197 @@
+package golox
+
+import (
+	"fmt"
+	"golox/internal/interpreter"
+	"reflect"
+)
+
+// Instance is an instance of a Lox class. Its fields are stored in Fields.
+type Instance = interpreter.LoxInstance
+
+var (
+	typeValue    = reflect.TypeOf(Value{})
+	typeInstance = reflect.TypeOf((*Instance)(nil))
+	typeError    = reflect.TypeOf((*error)(nil)).Elem()
+	typeFloat64  = reflect.TypeOf(float64(0))
+)
+
+// RegisterFunc defines a global Lox function name that calls the Go function
+// fn. Variadic Go functions are variadic in Lox too.
+//
+// Parameters of fn can be numbers, string, bool, *Instance, Value or any.
+// Lox arguments are converted to the parameter types, and a mismatched type
+// is a runtime error. fn can return nothing, a value converted by ValueOf, an
+// error, or a value and an error. A non-nil error is a runtime error at the
+// call site.
+func (e *Engine) RegisterFunc(name string, fn any) error {
+	native, err := newNativeFunction(name, fn)
+	if err != nil {
+		return err
+	}
+
+	e.natives[name] = native
+	e.runner.Interpreter().SetGlobal(name, native)
+	return nil
+}
+
+func newNativeFunction(name string, fn any) (*interpreter.NativeFunction, error) {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func {
+		return nil, fmt.Errorf("native function '%s' must be a Go function, got %T", name, fn)
+	}
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumIn(); i++ {
+		paramType := rt.In(i)
+		if rt.IsVariadic() && i == rt.NumIn()-1 {
+			paramType = paramType.Elem()
+		}
+		if !isParamTypeSupported(paramType) {
+			return nil, fmt.Errorf("native function '%s' has unsupported parameter type %s", name, paramType)
+		}
+	}
+
+	switch {
+	case rt.NumOut() == 0:
+	case rt.NumOut() == 1:
+	case rt.NumOut() == 2 && rt.Out(1) == typeError:
+	default:
+		return nil, fmt.Errorf("native function '%s' must return at most a value and an error", name)
+	}
+
+	arity := rt.NumIn()
+	if rt.IsVariadic() {
+		arity--
+	}
+
+	return interpreter.NewNativeFunction(name, arity, rt.IsVariadic(), func(args []any) (result any, resultErr error) {
+		defer func() {
+			if r := recover(); r != nil {
+				result, resultErr = nil, fmt.Errorf("panic: %v", r)
+			}
+		}()
+
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			var paramType reflect.Type
+			if rt.IsVariadic() && i >= rt.NumIn()-1 {
+				paramType = rt.In(rt.NumIn() - 1).Elem()
+			} else {
+				paramType = rt.In(i)
+			}
+
+			if val, err := convertArgument(arg, paramType); err != nil {
+				return nil, fmt.Errorf("argument %d: %w", i+1, err)
+			} else {
+				in[i] = val
+			}
+		}
+
+		return convertResults(rv.Call(in))
+	}), nil
+}
+
+func isParamTypeSupported(t reflect.Type) bool {
+	switch t {
+	case typeValue, typeInstance:
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Interface:
+		return t.NumMethod() == 0
+	default:
+		return false
+	}
+}
+
+func convertArgument(arg any, t reflect.Type) (reflect.Value, error) {
+	switch t {
+	case typeValue:
+		return reflect.ValueOf(Value{raw: arg}), nil
+	case typeInstance:
+		if ins, ok := arg.(*Instance); ok {
+			return reflect.ValueOf(ins), nil
+		}
+		return reflect.Value{}, newErrorArgumentType("an instance", arg)
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if arg == nil {
+			return reflect.Zero(t), nil
+		}
+		return reflect.ValueOf(arg), nil
+
+	case reflect.Bool:
+		if val, ok := arg.(bool); ok {
+			return reflect.ValueOf(val).Convert(t), nil
+		}
+		return reflect.Value{}, newErrorArgumentType("a bool", arg)
+
+	case reflect.String:
+		if val, ok := arg.(string); ok {
+			return reflect.ValueOf(val).Convert(t), nil
+		}
+		return reflect.Value{}, newErrorArgumentType("a string", arg)
+
+	case reflect.Float32, reflect.Float64:
+		if val, ok := arg.(float64); ok {
+			return reflect.ValueOf(val).Convert(t), nil
+		}
+		return reflect.Value{}, newErrorArgumentType("a number", arg)
+
+	default: // integers
+		if val, ok := arg.(float64); !ok {
+			return reflect.Value{}, newErrorArgumentType("an integer", arg)
+		} else if val != float64(int64(val)) {
+			return reflect.Value{}, fmt.Errorf("expected an integer, got %v", val)
+		} else if t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64 && val < 0 {
+			return reflect.Value{}, fmt.Errorf("expected a non-negative integer, got %v", val)
+		} else {
+			result := reflect.New(t).Elem()
+			if t.Kind() >= reflect.Uint {
+				result.SetUint(uint64(val))
+			} else {
+				result.SetInt(int64(val))
+			}
+			if result.Convert(typeFloat64).Float() != val {
+				return reflect.Value{}, fmt.Errorf("integer %v overflows %s", val, t)
+			}
+			return result, nil
+		}
+	}
+}
+
+func convertResults(out []reflect.Value) (any, error) {
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	last := out[len(out)-1]
+	if last.Type() == typeError {
+		if !last.IsNil() {
+			return nil, last.Interface().(error)
+		}
+		out = out[:len(out)-1]
+		if len(out) == 0 {
+			return nil, nil
+		}
+	}
+
+	if val, err := ValueOf(out[0].Interface()); err != nil {
+		return nil, err
+	} else {
+		return val.raw, nil
+	}
+}
+
+func newErrorArgumentType(want string, got any) error {
+	return fmt.Errorf("expected %s, got %s", want, Value{raw: got}.Kind())
+}
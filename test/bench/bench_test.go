@@ -0,0 +1,106 @@
+package bench_test
+
+import (
+	golox "golox/internal"
+	"golox/internal/bench"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_files_of_directories(t *testing.T) {
+	files, err := bench.Files([]string{"../test_files/benchmark"})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(files) != 10 {
+		t.Errorf("got %d files, want 10: %v", len(files), files)
+	}
+}
+
+func Test_files_skip_time_bound_scripts_of_directories(t *testing.T) {
+	files, err := bench.Files([]string{"../test_files/benchmark"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if filepath.Base(file) == "zoo_batch.lox" {
+			t.Errorf("got time-bound script %s", file)
+		}
+	}
+
+	// a time-bound script is still run when passed as a file
+	path := "../test_files/benchmark/zoo_batch.lox"
+	if files, err := bench.Files([]string{path}); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(files, []string{path}) {
+		t.Errorf("got files %v, want [%s]", files, path)
+	}
+}
+
+func Test_run_file(t *testing.T) {
+	result, err := bench.RunFile(golox.Config{}, "../main.lox", 3)
+	if err != nil {
+		t.Fatal(err)
+	} else if result.Name != "main" || result.Runs != 3 {
+		t.Errorf("got %+v, want 3 runs of main", result)
+	} else if result.MeanNs <= 0 || result.AllocsPerOp == 0 {
+		t.Errorf("got %+v, want a positive mean and allocs", result)
+	}
+}
+
+func Test_regressions(t *testing.T) {
+	baseline := &bench.Report{
+		Backend: "treewalk",
+		Results: []bench.Result{
+			{Name: "fib", Runs: 1, MeanNs: 100},
+			{Name: "zoo", Runs: 1, MeanNs: 100},
+		},
+	}
+	report := &bench.Report{
+		Backend: "treewalk",
+		Results: []bench.Result{
+			{Name: "fib", Runs: 1, MeanNs: 105},
+			{Name: "zoo", Runs: 1, MeanNs: 120},
+			{Name: "trees", Runs: 1, MeanNs: 1000},
+		},
+	}
+
+	if got, err := bench.Regressions(baseline, report, 0.1); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(got, []string{"zoo"}) {
+		t.Errorf("got regressions %v, want [zoo]", got)
+	}
+}
+
+func Test_regressions_of_another_backend(t *testing.T) {
+	baseline := &bench.Report{
+		Backend: "treewalk",
+		Results: []bench.Result{{Name: "fib", Runs: 1, MeanNs: 100}},
+	}
+	report := &bench.Report{
+		Backend: "vm",
+		Results: []bench.Result{{Name: "fib", Runs: 1, MeanNs: 10}},
+	}
+
+	if _, err := bench.Regressions(baseline, report, 0.1); err == nil {
+		t.Error("got no error comparing backends treewalk and vm")
+	}
+}
+
+func Test_save_and_load_report(t *testing.T) {
+	report := &bench.Report{
+		Backend: "vm",
+		Results: []bench.Result{
+			{Name: "fib", Runs: 5, MeanNs: 1.5e9, StddevNs: 2e7, AllocsPerOp: 42, BytesPerOp: 1024},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "baseline.json")
+
+	if err := bench.SaveReport(path, report); err != nil {
+		t.Fatal(err)
+	} else if loaded, err := bench.LoadReport(path); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(loaded, report) {
+		t.Errorf("got %+v, want %+v", loaded, report)
+	}
+}
@@ -0,0 +1,73 @@
+package concurrency_test
+
+import (
+	"bytes"
+	"fmt"
+	golox "golox/internal"
+	"golox/internal/runner"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	N_RUNNERS = 16
+)
+
+const source = `
+class Counter {
+  init(start) {
+    this.count = start;
+  }
+  inc() {
+    this.count = this.count + 1;
+    return this;
+  }
+}
+
+fun fib(n) {
+  if (n < 2) return n;
+  return fib(n - 2) + fib(n - 1);
+}
+
+var counter = Counter(id);
+for (var i = 0; i < 100; i = i + 1) {
+  counter.inc();
+}
+print counter.count;
+print fib(15);
+`
+
+// run with `go test -race` to detect data races between runners
+func Test_parallel_runners(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < N_RUNNERS; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+
+			var stdout, stderr bytes.Buffer
+			r := runner.NewRunner(golox.Config{
+				IsDebug: id%2 == 0, // mix debug and non-debug runners
+				Stdout:  &stdout,
+				Stderr:  &stderr,
+			})
+
+			r.Interpreter().SetGlobal("id", float64(id))
+			if _, err := r.RunSource([]rune(source), fmt.Sprintf("runner%d", id)); err != nil {
+				t.Errorf("runner %d: %v", id, err)
+				return
+			}
+
+			if got, want := stdout.String(), fmt.Sprintf("%d\n610\n", id+100); got != want {
+				t.Errorf("runner %d: got output %q, want %q", id, got, want)
+			}
+
+			isDebugLogged := strings.Contains(stderr.String(), "DEBUG")
+			if isDebug := id%2 == 0; isDebugLogged != isDebug {
+				t.Errorf("runner %d: debug logs written = %t, want %t", id, isDebugLogged, isDebug)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
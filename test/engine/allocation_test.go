@@ -0,0 +1,138 @@
+package engine_test
+
+import (
+	"errors"
+	"golox"
+	lox "golox/internal"
+	"golox/internal/runner"
+	"io"
+	"testing"
+)
+
+func Test_allocation_limits(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits golox.AllocationLimits
+		source string
+	}{
+		{
+			name:   "string bytes",
+			limits: golox.AllocationLimits{MaxStringBytes: 100},
+			source: `var s = "a"; while (true) s = s + s;`,
+		},
+		{
+			name:   "instances",
+			limits: golox.AllocationLimits{MaxInstances: 100},
+			source: `class A {} while (true) A();`,
+		},
+		{
+			name:   "fields",
+			limits: golox.AllocationLimits{MaxFields: 100},
+			source: `class A {} while (true) A().field = 1;`,
+		},
+		{
+			name:   "scopes",
+			limits: golox.AllocationLimits{MaxScopes: 100},
+			source: `fun f() {} while (true) f();`,
+		},
+		{
+			name:   "list literals",
+			limits: golox.AllocationLimits{MaxElements: 100},
+			source: `while (true) [1, 2, 3];`,
+		},
+		{
+			name:   "list push",
+			limits: golox.AllocationLimits{MaxElements: 100},
+			source: `var xs = []; while (true) xs.push(1);`,
+		},
+		{
+			name:   "list insert",
+			limits: golox.AllocationLimits{MaxElements: 100},
+			source: `var xs = []; while (true) xs.insert(0, 1);`,
+		},
+		{
+			name:   "list slice",
+			limits: golox.AllocationLimits{MaxElements: 100},
+			source: `var xs = [1, 2, 3]; while (true) xs.slice(0);`,
+		},
+		{
+			name:   "map literals",
+			limits: golox.AllocationLimits{MaxElements: 100},
+			source: `while (true) ({"a": 1, "b": 2});`,
+		},
+		{
+			name:   "map entries",
+			limits: golox.AllocationLimits{MaxElements: 100},
+			source: `var m = {}; var i = 0; while (true) { m[i] = i; i = i + 1; }`,
+		},
+		{
+			name:   "map keys",
+			limits: golox.AllocationLimits{MaxElements: 100},
+			source: `var m = {"a": 1, "b": 2}; while (true) m.keys();`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := golox.NewEngine(golox.Options{AllocationLimits: tt.limits})
+
+			_, err := engine.Eval(tt.source)
+
+			var diag *golox.Diagnostic
+			if !errors.As(err, &diag) || !errors.Is(err, golox.ErrAllocationLimit) {
+				t.Fatalf("got %v, want %v", err, golox.ErrAllocationLimit)
+			}
+			if diag.Code != golox.ErrorCodeAllocationLimit {
+				t.Errorf("got %s, want %s", diag.Code, golox.ErrorCodeAllocationLimit)
+			}
+		})
+	}
+}
+
+func Test_allocation_limits_within(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{
+		AllocationLimits: golox.AllocationLimits{
+			MaxStringBytes: 100,
+			MaxInstances:   10,
+			MaxFields:      10,
+			MaxScopes:      100,
+			MaxElements:    20,
+		},
+	})
+
+	// overwriting a field or a map entry is not a new allocation
+	source := `class A {} var a = A(); var xs = [];
+var m = {"k": 0};
+for (var i = 0; i < 10; i = i + 1) { a.field = "a" + "b"; xs.push(i); xs.pop(); m["k"] = i; }`
+	for i := 0; i < 3; i++ { // the limits are per run
+		if _, err := engine.Eval(source); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_element_limits_of_backends(t *testing.T) {
+	for _, backend := range []lox.Backend{lox.BackendTreeWalk, lox.BackendVM} {
+		for _, source := range []string{
+			`while (true) [1, 2, 3];`,
+			`var xs = []; while (true) xs.push(1);`,
+			`var xs = [1]; var push = xs.push; while (true) push(1);`,
+			`var xs = [1, 2, 3]; while (true) xs.slice(1);`,
+			`while (true) ({"a": 1});`,
+			`var m = {}; var i = 0; while (true) { m[i] = i; i = i + 1; }`,
+		} {
+			r := runner.NewRunner(lox.Config{
+				Backend:          backend,
+				Stdout:           io.Discard,
+				AllocationLimits: lox.AllocationLimits{MaxElements: 100},
+			})
+
+			_, err := r.RunSource([]rune(source), "elements.lox")
+
+			var diag *golox.Diagnostic
+			if !errors.As(err, &diag) || diag.Code != golox.ErrorCodeAllocationLimit {
+				t.Errorf("%s: %s: got %v, want %v", backend, source, err, golox.ErrAllocationLimit)
+			}
+		}
+	}
+}
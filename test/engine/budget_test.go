@@ -0,0 +1,216 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"golox"
+	lox "golox/internal"
+	"golox/internal/runner"
+	"io"
+	"testing"
+	"time"
+)
+
+func Test_timeout(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := engine.EvalContext(ctx, "while (true) {}")
+
+	if !errors.Is(err, golox.ErrCancelled) {
+		t.Fatalf("got %v, want %v", err, golox.ErrCancelled)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func Test_cancel(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	engine.RegisterFunc("cancel", func() { cancel() })
+
+	_, err := engine.EvalContext(ctx, "fun f() { cancel(); return f(); } f();")
+
+	var diag *golox.Diagnostic
+	if !errors.As(err, &diag) || !errors.Is(err, golox.ErrCancelled) {
+		t.Fatalf("got %v, want %v", err, golox.ErrCancelled)
+	}
+	if diag.Code != golox.ErrorCodeCancelled {
+		t.Errorf("got %s, want %s", diag.Code, golox.ErrorCodeCancelled)
+	}
+	if len(diag.StackTrace) == 0 {
+		t.Errorf("got no stack trace")
+	}
+}
+
+func Test_step_budget(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{MaxSteps: 100})
+
+	_, err := engine.Eval("for (var i = 0; i < 100; i = i + 1) {}")
+	if !errors.Is(err, golox.ErrBudgetExceeded) {
+		t.Fatalf("got %v, want %v", err, golox.ErrBudgetExceeded)
+	}
+
+	// the budget is per run
+	if _, err := engine.Eval("for (var i = 0; i < 50; i = i + 1) {}"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Eval("for (var i = 0; i < 50; i = i + 1) {}"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_step_budget_calls(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{MaxSteps: 10})
+
+	_, err := engine.Eval("fun f(n) { if (n > 0) f(n - 1); } f(20);")
+
+	if !errors.Is(err, golox.ErrBudgetExceeded) {
+		t.Fatalf("got %v, want %v", err, golox.ErrBudgetExceeded)
+	}
+}
+
+func Test_step_budget_of_imported_functions(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{MaxSteps: 100})
+	if _, err := engine.Eval(`import "lib.lox" as lib;`); err != nil {
+		t.Fatal(err)
+	}
+
+	// the steps of an imported function count in the run calling it, and
+	// are reset with it
+	for i := 0; i < 10; i++ {
+		if _, err := engine.Eval("lib.count(10);"); err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+	}
+	_, err := engine.Eval("lib.count(100);")
+	if !errors.Is(err, golox.ErrBudgetExceeded) {
+		t.Fatalf("got %v, want %v", err, golox.ErrBudgetExceeded)
+	}
+}
+
+func Test_cancel_imported_function(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+	if _, err := engine.Eval(`import "lib.lox" as lib;`); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := engine.EvalContext(ctx, "lib.spin();")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, golox.ErrCancelled) {
+			t.Fatalf("got %v, want %v", err, golox.ErrCancelled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the imported function was not cancelled")
+	}
+}
+
+func Test_call_depth_of_imported_functions(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{MaxCallDepth: 50})
+
+	// each level calls deep and lib.apply, so 40 levels need 80 frames
+	_, err := engine.Eval(`
+		import "lib.lox" as lib;
+		fun deep(n) { if (n > 0) lib.apply(deep, n - 1); }
+		deep(40);
+	`)
+
+	var diag *golox.Diagnostic
+	if !errors.As(err, &diag) || diag.Code != golox.ErrorCodeStackOverflow {
+		t.Fatalf("got %v, want a stack overflow", err)
+	}
+}
+
+func Test_budgets_of_imported_functions_of_backends(t *testing.T) {
+	for _, backend := range []lox.Backend{lox.BackendTreeWalk, lox.BackendVM} {
+		r := runner.NewRunner(lox.Config{Backend: backend, Stdout: io.Discard, MaxSteps: 100})
+		if _, err := r.RunSource([]rune(`import "lib.lox" as lib;`), "budget.lox"); err != nil {
+			t.Fatalf("%s: %v", backend, err)
+		}
+		for i := 0; i < 10; i++ {
+			if _, err := r.RunSource([]rune("lib.count(10);"), "budget.lox"); err != nil {
+				t.Fatalf("%s: run %d: %v", backend, i, err)
+			}
+		}
+		if _, err := r.RunSource([]rune("lib.count(100);"), "budget.lox"); !errors.Is(err, golox.ErrBudgetExceeded) {
+			t.Errorf("%s: got %v, want %v", backend, err, golox.ErrBudgetExceeded)
+		}
+
+		r = runner.NewRunner(lox.Config{Backend: backend, Stdout: io.Discard})
+		if _, err := r.RunSource([]rune(`import "lib.lox" as lib;`), "budget.lox"); err != nil {
+			t.Fatalf("%s: %v", backend, err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		done := make(chan error, 1)
+		go func() {
+			_, err := r.RunSourceContext(ctx, []rune("lib.spin();"), "budget.lox")
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if !errors.Is(err, golox.ErrCancelled) {
+				t.Errorf("%s: got %v, want %v", backend, err, golox.ErrCancelled)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: the imported function was not cancelled", backend)
+		}
+		cancel()
+	}
+}
+
+func Test_budgets_are_shared_with_imported_modules(t *testing.T) {
+	for _, backend := range []lox.Backend{lox.BackendTreeWalk, lox.BackendVM} {
+		for _, tt := range []struct {
+			config lox.Config
+			want   error
+		}{
+			{config: lox.Config{MaxSteps: 100}, want: golox.ErrBudgetExceeded},
+			{config: lox.Config{AllocationLimits: lox.AllocationLimits{MaxElements: 15}}, want: golox.ErrAllocationLimit},
+		} {
+			tt.config.Backend = backend
+			tt.config.Stdout = io.Discard
+			r := runner.NewRunner(tt.config)
+
+			// within the limits, but not with the top level of loop.lox
+			_, err := r.RunSource([]rune(`
+				for (var i = 0; i < 60; i = i + 1) {}
+				var ys = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10];
+				import "loop.lox" as loop;
+			`), "budget.lox")
+
+			if !errors.Is(err, tt.want) {
+				t.Errorf("%s: got %v, want %v", backend, err, tt.want)
+			}
+		}
+	}
+}
+
+func Test_call_depth_is_shared_with_imported_modules(t *testing.T) {
+	for _, backend := range []lox.Backend{lox.BackendTreeWalk, lox.BackendVM} {
+		r := runner.NewRunner(lox.Config{Backend: backend, Stdout: io.Discard, MaxCallDepth: 10})
+
+		// the top level of recurse.lox calls 6 levels deep
+		_, err := r.RunSource([]rune(`
+			fun deep(n) {
+				if (n > 0) return deep(n - 1);
+				import "recurse.lox" as recurse;
+			}
+			deep(5);
+		`), "budget.lox")
+
+		var diag *golox.Diagnostic
+		if !errors.As(err, &diag) || diag.Code != golox.ErrorCodeStackOverflow {
+			t.Errorf("%s: got %v, want a stack overflow", backend, err)
+		}
+	}
+}
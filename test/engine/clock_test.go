@@ -0,0 +1,40 @@
+package engine_test
+
+import (
+	"golox"
+	"testing"
+	"time"
+)
+
+// fakeClock advances by a second at each reading, including the reading of
+// the start of nanotime() when the engine is created.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	now := c.now
+	c.now = c.now.Add(time.Second)
+	return now
+}
+
+func Test_time_builtins_read_the_clock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 2, 3, 4, 5, 250_000_000, time.UTC)}
+	engine := golox.NewEngine(golox.Options{Clock: clock})
+
+	for _, tt := range []struct {
+		source string
+		want   any
+	}{
+		{"clock();", 1704164645.25 + 1},
+		{"nanotime();", float64(2 * time.Second)},
+		{"now();", "2024-01-02T03:04:08.25Z"},
+		{"var start = nanotime(); nanotime() - start;", float64(time.Second)},
+	} {
+		if val, err := engine.Eval(tt.source); err != nil {
+			t.Fatal(err)
+		} else if val.Interface() != tt.want {
+			t.Errorf("%s got %v, want %v", tt.source, val.Interface(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package engine_test
+
+import (
+	"errors"
+	"fmt"
+	"golox"
+	"testing"
+)
+
+func Example_diagnostics() {
+	engine := golox.NewEngine(golox.Options{})
+
+	_, err := engine.Eval("var a = ;\nprint (1;\n")
+
+	var diags golox.Diagnostics
+	if errors.As(err, &diags) {
+		for _, diag := range diags {
+			fmt.Println(diag.Phase, diag.Code, diag.Start.Line, diag.Start.Col, diag.Message)
+		}
+	}
+
+	// Output:
+	// parser P0001 1 9 expect expression
+	// parser P0001 2 9 expect closing ')'
+}
+
+func Test_diagnostic_runtime(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	_, err := engine.Eval(`print 1 + "a";`)
+
+	var diag *golox.Diagnostic
+	if !errors.As(err, &diag) {
+		t.Fatalf("got %T, want a diagnostic", err)
+	}
+	if diag.Phase != golox.PhaseRuntime || diag.Code != golox.ErrorCodeInvalidOperand {
+		t.Errorf("got %s %s, want %s %s", diag.Phase, diag.Code, golox.PhaseRuntime, golox.ErrorCodeInvalidOperand)
+	}
+	if want := (golox.Location{SrcPath: "<eval#1>", Line: 1, Col: 9}); diag.Start != want {
+		t.Errorf("got start %s, want %s", diag.Start, want)
+	}
+	if want := (golox.Location{SrcPath: "<eval#1>", Line: 1, Col: 10}); diag.End != want {
+		t.Errorf("got end %s, want %s", diag.End, want)
+	}
+	if got, want := err.Error(), `<eval#1>:1:9: operands must be both numbers or both strings`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func Test_diagnostic_labels(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	_, err := engine.Eval("{\n  print 1;\n")
+
+	var diag *golox.Diagnostic
+	if !errors.As(err, &diag) {
+		t.Fatalf("got %T, want a diagnostic", err)
+	}
+	if diag.Code != golox.ErrorCodeUnclosedBlock {
+		t.Errorf("got %s, want %s", diag.Code, golox.ErrorCodeUnclosedBlock)
+	}
+	if len(diag.Labels) != 1 {
+		t.Fatalf("got labels %v, want 1 label", diag.Labels)
+	}
+	if want := (golox.Location{SrcPath: "<eval#1>", Line: 1, Col: 1}); diag.Labels[0].Start != want {
+		t.Errorf("got label at %s, want %s", diag.Labels[0].Start, want)
+	}
+}
@@ -0,0 +1,174 @@
+package engine_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"golox"
+	lox "golox/internal"
+	"golox/internal/runner"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Example_eval() {
+	engine := golox.NewEngine(golox.Options{})
+
+	val, err := engine.Eval("var a = 1; a + 2;")
+	if err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(val.Kind(), val)
+
+	// Output:
+	// number 3
+}
+
+func Example_eval_persists_globals() {
+	engine := golox.NewEngine(golox.Options{})
+
+	if _, err := engine.Eval("var a = 1;"); err != nil {
+		fmt.Println(err)
+	}
+	val, err := engine.Eval("a = a + 1;")
+	if err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(val)
+
+	// Output:
+	// 2
+}
+
+func Example_eval_no_result() {
+	engine := golox.NewEngine(golox.Options{})
+
+	val, err := engine.Eval("var a = 1;")
+	if err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(val.IsNil())
+
+	// Output:
+	// true
+}
+
+func Example_globals() {
+	engine := golox.NewEngine(golox.Options{})
+
+	if err := engine.SetGlobal("name", "lox"); err != nil {
+		fmt.Println(err)
+	}
+	if _, err := engine.RunFile("globals.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	greeting, _ := engine.Global("greeting")
+	s, _ := greeting.AsString()
+	fmt.Println(s)
+
+	double, _ := engine.Global("double")
+	fmt.Println(double.Kind(), double)
+
+	// Output:
+	// hello, lox
+	// function <fn: double>
+}
+
+func Test_set_global_converts_numbers(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	if err := engine.SetGlobal("n", 21); err != nil {
+		t.Fatal(err)
+	}
+	val, err := engine.Eval("n * 2;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := val.AsNumber(); !ok || n != 42 {
+		t.Errorf("got %v, want 42", val)
+	}
+}
+
+func Test_set_global_unsupported_type(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	if err := engine.SetGlobal("ch", make(chan int)); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+}
+
+func Test_undefined_global(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	if _, ok := engine.Global("undefined"); ok {
+		t.Error("expected undefined global")
+	}
+}
+
+func Test_eval_runtime_error(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	if _, err := engine.Eval("1 + nil;"); err == nil {
+		t.Error("expected runtime error")
+	}
+}
+
+func Test_reset(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	if _, err := engine.Eval("var a = 1;"); err != nil {
+		t.Fatal(err)
+	}
+	engine.Reset()
+	if _, ok := engine.Global("a"); ok {
+		t.Error("expected globals to be dropped")
+	}
+}
+
+func Test_run_file_by_absolute_path(t *testing.T) {
+	path, err := filepath.Abs("import.lox")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, backend := range []lox.Backend{lox.BackendTreeWalk, lox.BackendVM} {
+		var stdout bytes.Buffer
+		r := runner.NewRunner(lox.Config{Backend: backend, Stdout: &stdout})
+
+		// the imports are found next to the script
+		if err := r.RunFile(path); err != nil {
+			t.Fatalf("%s: %v", backend, err)
+		}
+		if got := stdout.String(); got != "42\n" {
+			t.Errorf("%s: got %q, want %q", backend, got, "42\n")
+		}
+	}
+}
+
+func Test_unreadable_module(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("the permissions of files do not apply to root")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "locked.lox")
+	if err := os.WriteFile(path, []byte("export var a = 1;\n"), 0o000); err != nil {
+		t.Fatal(err)
+	}
+
+	r := runner.NewRunner(lox.Config{})
+	_, err := r.RunSource([]rune(`import "locked.lox" as locked;`), filepath.Join(dir, "main.lox"))
+
+	var diag *golox.Diagnostic
+	if !errors.As(err, &diag) || diag.Code != golox.ErrorCodeModuleUnreadable {
+		t.Fatalf("got %v, want %s", err, golox.ErrorCodeModuleUnreadable)
+	}
+	if want := (golox.Location{SrcPath: filepath.Join(dir, "main.lox"), Line: 1, Col: 8}); diag.Start != want {
+		t.Errorf("got %v, want %v", diag.Start, want)
+	}
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("got %v, want %v", err, fs.ErrPermission)
+	}
+}
@@ -0,0 +1,163 @@
+package engine_test
+
+import (
+	"errors"
+	"fmt"
+	"golox"
+	"strings"
+	"testing"
+)
+
+func Example_register_func() {
+	engine := golox.NewEngine(golox.Options{})
+
+	if err := engine.RegisterFunc("repeat", strings.Repeat); err != nil {
+		fmt.Println(err)
+	}
+	if err := engine.RegisterFunc("sum", func(nums ...float64) float64 {
+		total := 0.0
+		for _, n := range nums {
+			total += n
+		}
+		return total
+	}); err != nil {
+		fmt.Println(err)
+	}
+
+	val, err := engine.Eval(`repeat("ab", 3);`)
+	if err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(val)
+
+	val, err = engine.Eval(`sum(1, 2, 3, 4);`)
+	if err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(val)
+
+	val, err = engine.Eval(`sum;`)
+	if err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(val)
+
+	// Output:
+	// "ababab"
+	// 10
+	// <native fn: sum>
+}
+
+func Example_register_func_instance() {
+	engine := golox.NewEngine(golox.Options{})
+
+	if err := engine.RegisterFunc("describe", func(ins *golox.Instance) string {
+		return fmt.Sprintf("%s has %d fields", ins.Class.Identifier.Lexeme, len(ins.Fields))
+	}); err != nil {
+		fmt.Println(err)
+	}
+
+	val, err := engine.Eval(`
+class Point {
+  init(x, y) {
+    this.x = x;
+    this.y = y;
+  }
+}
+describe(Point(1, 2));
+`)
+	if err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(val)
+
+	// Output:
+	// "Point has 2 fields"
+}
+
+func Test_register_func_error(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	errBoom := errors.New("boom")
+	if err := engine.RegisterFunc("fail", func() error {
+		return errBoom
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := engine.Eval("\n  fail();")
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("got %v, want %v", err, errBoom)
+	}
+	if !strings.Contains(err.Error(), "<eval#1>:2:3") {
+		t.Errorf("error %q does not contain the call-site location", err)
+	}
+}
+
+func Test_register_func_argument_type_mismatch(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	if err := engine.RegisterFunc("half", func(n int) int {
+		return n / 2
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range []string{
+		`half("1");`,
+		`half(1.5);`,
+		`half();`,
+		`half(1, 2);`,
+	} {
+		if _, err := engine.Eval(source); err == nil {
+			t.Errorf("%s: expected runtime error", source)
+		} else {
+			t.Log(err)
+		}
+	}
+}
+
+func Test_register_func_variadic_arity(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	if err := engine.RegisterFunc("join", func(sep string, parts ...string) string {
+		return strings.Join(parts, sep)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := engine.Eval(`join();`); err == nil {
+		t.Error("expected arity error")
+	}
+	if val, err := engine.Eval(`join("-", "a", "b");`); err != nil {
+		t.Error(err)
+	} else if s, _ := val.AsString(); s != "a-b" {
+		t.Errorf("got %v, want a-b", val)
+	}
+}
+
+func Test_register_func_unsupported(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	for _, fn := range []any{
+		42,
+		func(ch chan int) {},
+		func() (int, int) { return 0, 0 },
+	} {
+		if err := engine.RegisterFunc("fn", fn); err == nil {
+			t.Errorf("%T: expected error", fn)
+		}
+	}
+}
+
+func Test_register_func_survives_reset(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	if err := engine.RegisterFunc("one", func() int { return 1 }); err != nil {
+		t.Fatal(err)
+	}
+	engine.Reset()
+	if _, err := engine.Eval("one();"); err != nil {
+		t.Error(err)
+	}
+}
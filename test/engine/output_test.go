@@ -0,0 +1,39 @@
+package engine_test
+
+import (
+	"bytes"
+	"golox"
+	"strings"
+	"testing"
+)
+
+func Test_stdout_is_redirected(t *testing.T) {
+	var stdout bytes.Buffer
+	engine := golox.NewEngine(golox.Options{Stdout: &stdout})
+
+	if _, err := engine.Eval(`print "a"; print 1 + 2;`); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stdout.String(), "\"a\"\n3\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func Test_debug_logs_are_redirected(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	engine := golox.NewEngine(golox.Options{
+		IsDebug: true,
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+	})
+
+	if _, err := engine.Eval(`print "a";`); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stdout.String(), "\"a\"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !strings.Contains(stderr.String(), "StatementPrint") {
+		t.Errorf("debug logs %q do not mention the print statement", stderr.String())
+	}
+}
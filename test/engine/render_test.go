@@ -0,0 +1,149 @@
+package engine_test
+
+import (
+	"bytes"
+	"fmt"
+	"golox"
+	lox "golox/internal"
+	"golox/internal/runner"
+	"strings"
+	"testing"
+)
+
+func Test_render_runtime_error(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	_, err := engine.Eval("var a = 1;\nprint a - \"x\";\n")
+
+	var b bytes.Buffer
+	engine.RenderError(&b, err, false)
+
+	want := strings.Join([]string{
+		`error[E0002]: operands must be both numbers`,
+		` --> <eval#1>:2:9`,
+		`  |`,
+		`2 | print a - "x";`,
+		`  |         ^`,
+		`  |`,
+		``,
+	}, "\n")
+	if got := b.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func Test_render_secondary_label(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	_, err := engine.Eval("print 1;\nprint \"abc;\n")
+
+	var b bytes.Buffer
+	engine.RenderError(&b, err, false)
+
+	want := strings.Join([]string{
+		`error[L0002]: unterminated string`,
+		` --> <eval#1>:3:1`,
+		`  |`,
+		`2 | print "abc;`,
+		`  |       - string started here`,
+		`3 | `,
+		`  | ^`,
+		`  |`,
+		``,
+	}, "\n")
+	if got := b.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func Test_render_token_span(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	_, err := engine.Eval("print undefinedVar;")
+
+	var b bytes.Buffer
+	engine.RenderError(&b, err, false)
+
+	if !strings.Contains(b.String(), "  |       ^^^^^^^^^^^^\n") {
+		t.Errorf("got\n%s\nwant the whole identifier underlined", b.String())
+	}
+}
+
+func Test_render_colored(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	_, err := engine.Eval("print -nil;")
+
+	var b bytes.Buffer
+	engine.RenderError(&b, err, true)
+
+	if !strings.Contains(b.String(), "\x1b[") {
+		t.Errorf("got\n%s\nwant ANSI escape codes", b.String())
+	}
+}
+
+func Test_render_error_of_function_of_earlier_eval(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+	if _, err := engine.Eval("fun f() {\n  var b = 2;\n  return b + nil;\n}\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := engine.Eval("f();")
+
+	var b bytes.Buffer
+	engine.RenderError(&b, err, false)
+
+	want := strings.Join([]string{
+		`error[E0002]: operands must be both numbers or both strings`,
+		` --> <eval#1>:3:12`,
+		`  |`,
+		`3 |   return b + nil;`,
+		`  |            ^`,
+		`  |`,
+		`  = stack trace:`,
+		`      in f, called at <eval#2>:1:1`,
+		``,
+	}, "\n")
+	if got := b.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func Test_render_drops_sources_of_old_evals(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+	if _, err := engine.Eval("fun f() {\n  return nil + 1;\n}\n"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		if _, err := engine.Eval("1;"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := engine.Eval("f();")
+
+	var b bytes.Buffer
+	engine.RenderError(&b, err, false)
+
+	// the source of f is dropped, so its error has no source line
+	if got := b.String(); !strings.Contains(got, " --> <eval#1>:2:14") || strings.Contains(got, "return") {
+		t.Errorf("got\n%s\nwant the location of f without its source line", got)
+	}
+}
+
+func Test_renderer_does_not_grow_with_runs(t *testing.T) {
+	r := runner.NewRunner(lox.Config{})
+	for i := 0; i < 1000; i++ {
+		if _, err := r.RunSource([]rune("1;"), fmt.Sprintf("<eval#%d>", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(r.Renderer().SrcPaths()); n > 100 {
+		t.Errorf("got %d sources after 1000 runs", n)
+	}
+
+	r.Reset()
+	if srcPaths := r.Renderer().SrcPaths(); len(srcPaths) > 0 {
+		t.Errorf("got sources %v after a reset", srcPaths)
+	}
+}
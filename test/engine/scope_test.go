@@ -0,0 +1,27 @@
+package engine_test
+
+import (
+	"golox"
+	"testing"
+)
+
+func Test_scopes_are_restored_after_errors(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	for _, source := range []string{
+		"{ var a = 1; nil + 1; }",
+		"fun f() { { var a = 1; nil + 1; } } f();",
+		"fun f() { { return; } } f(); { nil + 1; }",
+	} {
+		if _, err := engine.Eval(source); err == nil {
+			t.Fatalf("got no error for %q", source)
+		}
+	}
+
+	if _, err := engine.Eval("var b = 2;"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := engine.Global("b"); !ok {
+		t.Errorf("b is not defined in the global scope")
+	}
+}
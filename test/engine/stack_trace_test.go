@@ -0,0 +1,95 @@
+package engine_test
+
+import (
+	"bytes"
+	"errors"
+	"golox"
+	"strings"
+	"testing"
+)
+
+const stackTraceSource = `class A {
+  init() {
+    this.f();
+  }
+  f() {
+    return g();
+  }
+}
+fun g() {
+  return nil + 1;
+}
+A();
+`
+
+func Test_stack_trace(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	_, err := engine.Eval(stackTraceSource)
+
+	var diag *golox.Diagnostic
+	if !errors.As(err, &diag) {
+		t.Fatalf("got %T, want a diagnostic", err)
+	}
+	want := []golox.StackFrame{
+		{FunctionName: "g", CallSite: golox.Location{SrcPath: "<eval#1>", Line: 6, Col: 12}},
+		{FunctionName: "f", ClassName: "A", CallSite: golox.Location{SrcPath: "<eval#1>", Line: 3, Col: 5}},
+		{FunctionName: "init", ClassName: "A", CallSite: golox.Location{SrcPath: "<eval#1>", Line: 12, Col: 1}},
+	}
+	if len(diag.StackTrace) != len(want) {
+		t.Fatalf("got stack trace %v, want %v", diag.StackTrace, want)
+	}
+	for i := range want {
+		if diag.StackTrace[i] != want[i] {
+			t.Errorf("got frame %d %v, want %v", i, diag.StackTrace[i], want[i])
+		}
+	}
+}
+
+func Test_stack_trace_native(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+	engine.RegisterFunc("fail", func() error { return errors.New("boom") })
+
+	_, err := engine.Eval("fun f() {\n  fail();\n}\nf();\n")
+
+	var diag *golox.Diagnostic
+	if !errors.As(err, &diag) {
+		t.Fatalf("got %T, want a diagnostic", err)
+	}
+	if len(diag.StackTrace) != 2 || diag.StackTrace[0].FunctionName != "fail" || diag.StackTrace[1].FunctionName != "f" {
+		t.Errorf("got stack trace %v, want fail and f", diag.StackTrace)
+	}
+}
+
+func Test_stack_trace_top_level(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	_, err := engine.Eval("print nil + 1;")
+
+	var diag *golox.Diagnostic
+	if !errors.As(err, &diag) {
+		t.Fatalf("got %T, want a diagnostic", err)
+	}
+	if diag.StackTrace != nil {
+		t.Errorf("got stack trace %v, want none", diag.StackTrace)
+	}
+}
+
+func Test_stack_trace_render(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+
+	_, err := engine.Eval(stackTraceSource)
+	var b bytes.Buffer
+	engine.RenderError(&b, err, false)
+
+	for _, want := range []string{
+		"= stack trace:",
+		"in g, called at <eval#1>:6:12",
+		"in A.f, called at <eval#1>:3:5",
+		"in A.init, called at <eval#1>:12:1",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("got %q, want it to contain %q", b.String(), want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package engine_test
+
+import (
+	"golox"
+	"strings"
+	"testing"
+)
+
+func Test_kinds(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+	if _, err := engine.Eval("fun f() {} class C {}"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		source string
+		want   golox.Kind
+	}{
+		{source: "nil;", want: golox.KindNil},
+		{source: "true;", want: golox.KindBool},
+		{source: "1;", want: golox.KindNumber},
+		{source: `"s";`, want: golox.KindString},
+		{source: "f;", want: golox.KindFunction},
+		{source: "clock;", want: golox.KindFunction},
+		{source: "C;", want: golox.KindClass},
+		{source: "C();", want: golox.KindInstance},
+		{source: "[1, 2];", want: golox.KindList},
+		{source: `({"a": 1});`, want: golox.KindMap},
+		{source: "var e; try { nil + 1; } catch (err) { e = err; } e;", want: golox.KindError},
+		{source: `import "lib.lox" as lib; lib;`, want: golox.KindModule},
+	}
+	for _, tt := range tests {
+		val, err := engine.Eval(tt.source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kind := val.Kind(); kind != tt.want {
+			t.Errorf("%s: got kind %s, want %s", tt.source, kind, tt.want)
+		}
+	}
+}
+
+func Test_kind_unknown(t *testing.T) {
+	if kind := (golox.Value{}).Kind(); kind != golox.KindNil {
+		t.Errorf("got kind %s of the zero Value, want nil", kind)
+	}
+	if s := golox.KindUnknown.String(); s != "unknown" {
+		t.Errorf("got %q", s)
+	}
+}
+
+func Test_argument_type_mismatch_names_the_kind(t *testing.T) {
+	engine := golox.NewEngine(golox.Options{})
+	if err := engine.RegisterFunc("half", func(n float64) float64 {
+		return n / 2
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := engine.Eval("half([1]);")
+	if err == nil || !strings.Contains(err.Error(), "expected a number, got list") {
+		t.Errorf("got error %v", err)
+	}
+}
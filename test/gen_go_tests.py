@@ -8,6 +8,7 @@ def header(module_name):
 
 import (
 	"fmt"
+	golox "golox/internal"
 	"golox/internal/runner"
 	"os"
 	"testing"
@@ -28,7 +29,7 @@ var (
 )
 
 func TestMain(m *testing.M) {{
-	r = runner.NewRunner(false)
+	r = runner.NewRunner(golox.Config{{}})
 
 	// run tests
 	os.Exit(m.Run())
@@ -0,0 +1,208 @@
+package lineedit_test
+
+import (
+	"errors"
+	"golox/internal/lineedit"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	up        = "\x1b[A"
+	down      = "\x1b[B"
+	left      = "\x1b[D"
+	home      = "\x1b[H"
+	del       = "\x1b[3~"
+	backspace = "\x7f"
+	ctrlC     = "\x03"
+	ctrlD     = "\x04"
+	ctrlG     = "\x07"
+	ctrlK     = "\x0b"
+	ctrlR     = "\x12"
+	ctrlU     = "\x15"
+	ctrlW     = "\x17"
+)
+
+// readLines returns the lines read from the typed keys, until the end of keys
+// or an error.
+func readLines(t *testing.T, history *lineedit.History, completer lineedit.Completer, keys string) ([]string, error) {
+	t.Helper()
+
+	e := lineedit.NewEditor(strings.NewReader(keys), io.Discard, history, completer)
+	var lines []string
+	for {
+		line, err := e.ReadLine("> ")
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		} else if err != nil {
+			return lines, err
+		}
+		lines = append(lines, line)
+	}
+}
+
+func Test_editing_keys(t *testing.T) {
+	tests := []struct {
+		name string
+		keys string
+		want string
+	}{
+		{name: "insert", keys: "print 1;\r", want: "print 1;"},
+		{name: "backspace", keys: "print 12" + backspace + ";\r", want: "print 1;"},
+		{name: "left and insert", keys: "print ;" + left + "1\r", want: "print 1;"},
+		{name: "home and delete", keys: "xprint 1;" + home + del + "\r", want: "print 1;"},
+		{name: "kill to end", keys: "print 1; 2" + left + left + ctrlK + "\r", want: "print 1;"},
+		{name: "kill to start", keys: "var a;print 1;" + left + left + left + left + left + left + left + left + ctrlU + "\r", want: "print 1;"},
+		{name: "delete word", keys: "print a b" + ctrlW + ctrlW + "1;\r", want: "print 1;"},
+		{name: "unicode", keys: "print \"é\";" + left + left + "ü\r", want: "print \"éü\";"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := readLines(t, lineedit.NewHistory(), nil, tt.keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(lines) != 1 || lines[0] != tt.want {
+				t.Errorf("got lines %q, want %q", lines, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ctrl_c_discards_the_line(t *testing.T) {
+	e := lineedit.NewEditor(strings.NewReader("print 1;"+ctrlC), io.Discard, lineedit.NewHistory(), nil)
+	if _, err := e.ReadLine("> "); !errors.Is(err, lineedit.ErrInterrupted) {
+		t.Errorf("got error %v, want %v", err, lineedit.ErrInterrupted)
+	}
+}
+
+func Test_ctrl_d_ends_an_empty_line(t *testing.T) {
+	lines, err := readLines(t, lineedit.NewHistory(), nil, "ab"+left+ctrlD+"\r"+ctrlD+"print 1;\r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("got lines %q, want %q", lines, want)
+	}
+}
+
+func Test_history_is_browsed_with_arrows(t *testing.T) {
+	history := lineedit.NewHistory()
+	keys := "a\rb\rc\r" + up + up + "2\r" + up + up + up + down + "3\r" + "d" + up + down + "\r"
+	lines, err := readLines(t, history, nil, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a", "b", "c", "b2", "c3", "d"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("got lines %q, want %q", lines, want)
+	}
+	if !reflect.DeepEqual(history.Lines(), want) {
+		t.Errorf("got history %q, want %q", history.Lines(), want)
+	}
+}
+
+func Test_reverse_search(t *testing.T) {
+	tests := []struct {
+		name string
+		keys string
+		want string
+	}{
+		{name: "latest match", keys: ctrlR + "print\r", want: "print 2;"},
+		{name: "older match", keys: ctrlR + "print" + ctrlR + "\r", want: "print 1;"},
+		{name: "cursor at match", keys: ctrlR + "1" + del + "3\r", want: "print 3;"},
+		{name: "backspace in query", keys: ctrlR + "printx" + backspace + "\r", want: "print 2;"},
+		{name: "cancel", keys: "x" + ctrlR + "print" + ctrlG + "\r", want: "x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := lineedit.NewHistory()
+			for _, line := range []string{"var a = 1;", "print 1;", "print 2;"} {
+				history.Add(line)
+			}
+			lines, err := readLines(t, history, nil, tt.keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(lines) != 1 || lines[0] != tt.want {
+				t.Errorf("got lines %q, want %q", lines, tt.want)
+			}
+		})
+	}
+}
+
+func Test_tab_completes_the_common_prefix(t *testing.T) {
+	var lines []string
+	completer := func(line []rune) ([]string, int) {
+		lines = append(lines, string(line))
+		start := strings.LastIndex(string(line), " ") + 1
+		var candidates []string
+		for _, name := range []string{"counter", "count", "print"} {
+			if strings.HasPrefix(name, string(line[start:])) {
+				candidates = append(candidates, name)
+			}
+		}
+		return candidates, start
+	}
+	got, err := readLines(t, lineedit.NewHistory(), completer, "pr\t c\t\ter;\r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"print counter;"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got lines %q, want %q", got, want)
+	}
+	if want := []string{"pr", "print c", "print count"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("completed %q, want %q", lines, want)
+	}
+}
+
+func Test_history_file(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golox", "history")
+	history, err := lineedit.LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readLines(t, history, nil, "var a = 1;\r \rprint a;\rprint a;\r"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the lines are loaded by the next sessions, without the blank and
+	// repeated lines
+	history, err = lineedit.LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"var a = 1;", "print a;"}; !reflect.DeepEqual(history.Lines(), want) {
+		t.Errorf("got history %q, want %q", history.Lines(), want)
+	}
+}
+
+func Test_history_file_is_truncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	var b strings.Builder
+	for i := 0; i < lineedit.MaxHistory+10; i++ {
+		b.WriteString("line\n")
+	}
+	b.WriteString("last\n")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := lineedit.LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := history.Lines()
+	if len(lines) != lineedit.MaxHistory || lines[len(lines)-1] != "last" {
+		t.Errorf("got %d lines ending with %q", len(lines), lines[len(lines)-1])
+	}
+	if content, err := os.ReadFile(path); err != nil {
+		t.Fatal(err)
+	} else if n := strings.Count(string(content), "\n"); n != lineedit.MaxHistory {
+		t.Errorf("got %d lines in the file, want %d", n, lineedit.MaxHistory)
+	}
+}
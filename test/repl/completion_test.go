@@ -0,0 +1,69 @@
+package repl_test
+
+import (
+	golox "golox/internal"
+	"golox/internal/runner"
+	"io"
+	"reflect"
+	"testing"
+)
+
+const completionSource = `
+var count = 1;
+var counter = 2;
+class Base {
+  greet() {}
+}
+class Point < Base {
+  init() {
+    this.x = 1;
+    this.next = nil;
+  }
+  norm() {}
+}
+var p = Point();
+p.next = Point();
+import "../test_files/import/modules/math.lox" as math;
+`
+
+// complete runs completionSource on backend, and returns the completions of
+// line.
+func complete(t *testing.T, backend golox.Backend, line string) ([]string, int) {
+	t.Helper()
+
+	r := runner.NewRunner(golox.Config{Backend: backend, Stdout: io.Discard})
+	if _, err := r.RunSource([]rune(completionSource), "completion.lox"); err != nil {
+		t.Fatal(err)
+	}
+	return r.Complete([]rune(line))
+}
+
+func Test_completion(t *testing.T) {
+	tests := []struct {
+		line      string
+		wantNames []string
+		wantStart int
+	}{
+		{line: "print cou", wantNames: []string{"count", "counter"}, wantStart: 6},
+		{line: "wh", wantNames: []string{"while"}, wantStart: 0},
+		{line: "print cl", wantNames: []string{"class", "clock"}, wantStart: 6},
+		{line: "p.", wantNames: []string{"greet", "init", "next", "norm", "x"}, wantStart: 2},
+		{line: "p.n", wantNames: []string{"next", "norm"}, wantStart: 2},
+		{line: "print p.next.x", wantNames: []string{"x"}, wantStart: 13},
+		{line: "math.", wantNames: []string{"Point", "pi", "square"}, wantStart: 5},
+		{line: "undefined.", wantNames: []string{}, wantStart: 10},
+		{line: "count.", wantNames: []string{}, wantStart: 6},
+		{line: "p.x.", wantNames: []string{}, wantStart: 4},
+		{line: "1.", wantNames: []string{}, wantStart: 2},
+	}
+	for _, backend := range []golox.Backend{golox.BackendTreeWalk, golox.BackendVM} {
+		for _, tt := range tests {
+			t.Run(backend.String()+"/"+tt.line, func(t *testing.T) {
+				names, start := complete(t, backend, tt.line)
+				if !reflect.DeepEqual(names, tt.wantNames) || start != tt.wantStart {
+					t.Errorf("got %q at %d, want %q at %d", names, start, tt.wantNames, tt.wantStart)
+				}
+			})
+		}
+	}
+}
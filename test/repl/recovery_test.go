@@ -0,0 +1,94 @@
+package repl_test
+
+import (
+	"bytes"
+	golox "golox/internal"
+	"golox/internal/runner"
+	"strings"
+	"testing"
+)
+
+// recoveryInputs alternate inputs failing in blocks, calls, closures and
+// handlers with inputs checking that variables still resolve to the global
+// scope.
+const recoveryInputs = `var a = "global";
+{ var a = "block"; { var b = a; nil + 1; } }
+print a;
+fun f(x) { var a = "local"; { var b = x; return b + nil; } }
+f(1);
+print a;
+fun g() { return a; }
+print g();
+fun counter() { var n = 0; fun inc() { n = n + 1; if (n == 2) { nil + 1; } return n; } return inc; }
+var inc = counter();
+inc();
+inc();
+print inc();
+fun deep() { var a = "deep"; deep(); }
+deep();
+print a;
+try { var a = "try"; nil + 1; } catch (e) { var c = "catch"; nil - 1; }
+print a;
+try { throw "t"; } finally { var d = "finally"; nil * 1; }
+print a;
+class C { init() { var a = "init"; this.a = nil + 1; } }
+C();
+print a;
+{ var x = "x"; print x; }
+`
+
+func Test_session_recovers_from_runtime_errors(t *testing.T) {
+	for _, backend := range []golox.Backend{golox.BackendTreeWalk, golox.BackendVM} {
+		t.Run(backend.String(), func(t *testing.T) {
+			var stdout bytes.Buffer
+			var errs []error
+			r := runner.NewRunner(golox.Config{
+				Backend:      backend,
+				Stdin:        strings.NewReader(recoveryInputs),
+				Stdout:       &stdout,
+				MaxCallDepth: 64,
+			})
+			r.RunPrompt(func(err error) {
+				errs = append(errs, err)
+			})
+
+			if len(errs) != 7 {
+				t.Errorf("got %d errors, want 7:\n%v", len(errs), errs)
+			}
+			var printed []string
+			for _, line := range strings.Split(stdout.String(), "\n") {
+				if line = strings.TrimLeft(line, "> "); line != "" && line != strings.TrimSuffix(banner, "\n") {
+					printed = append(printed, line)
+				}
+			}
+			want := []string{`"global"`, `"global"`, `"global"`, "1", "3", `"global"`, `"global"`, `"global"`, `"global"`, `"x"`}
+			if strings.Join(printed, ",") != strings.Join(want, ",") {
+				t.Errorf("got outputs %q, want %q", printed, want)
+			}
+		})
+	}
+}
+
+func Test_runner_recovers_from_runtime_errors(t *testing.T) {
+	for _, backend := range []golox.Backend{golox.BackendTreeWalk, golox.BackendVM} {
+		t.Run(backend.String(), func(t *testing.T) {
+			r := runner.NewRunner(golox.Config{Backend: backend})
+			for _, source := range []string{
+				"var a = 1;",
+				"{ var a = 2; { var b = 3; nil + 1; } }",
+				"fun f() { var a = 4; { var c = 5; return nil - c; } } f();",
+				"fun g() { var a = 6; g(); } g();",
+			} {
+				r.RunSource([]rune(source), "recovery.lox")
+			}
+
+			val, err := r.RunSource([]rune("{ var d = 7; a + d; }\na;"), "recovery.lox")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if val != 1.0 {
+				t.Errorf("got a = %v, want 1", val)
+			}
+		})
+	}
+}
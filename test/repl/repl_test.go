@@ -0,0 +1,215 @@
+package repl_test
+
+import (
+	"bytes"
+	golox "golox/internal"
+	"golox/internal/runner"
+	"strings"
+	"testing"
+)
+
+const banner = "An interactive session of golox. Press Ctrl-d to end, or type :help for commands.\n"
+
+// runPrompt runs an interactive session reading input, and returns its outputs
+// and errors.
+func runPrompt(t *testing.T, input string) (string, []error) {
+	t.Helper()
+
+	var stdout bytes.Buffer
+	var errs []error
+	r := runner.NewRunner(golox.Config{Stdin: strings.NewReader(input), Stdout: &stdout})
+	r.RunPrompt(func(err error) {
+		errs = append(errs, err)
+	})
+	return stdout.String(), errs
+}
+
+func Test_banner_is_printed_once(t *testing.T) {
+	output, _ := runPrompt(t, "print 1;\nprint 2;\n")
+	if n := strings.Count(output, "An interactive session"); n != 1 {
+		t.Errorf("got %d banners in:\n%s", n, output)
+	}
+}
+
+func Test_multi_line_input(t *testing.T) {
+	output, errs := runPrompt(t, "fun add(a,\n  b) {\n  return a + b;\n}\nprint add(1, 2);\n")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	want := banner + "> ... ... ... > 3\n> \n"
+	if output != want {
+		t.Errorf("got output:\n%q\nwant:\n%q", output, want)
+	}
+}
+
+func Test_multi_line_string(t *testing.T) {
+	output, errs := runPrompt(t, "print \"a\nb\";\n")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if !strings.Contains(output, "> ... \"a\nb\"\n") {
+		t.Errorf("got output:\n%q", output)
+	}
+}
+
+func Test_values_of_expression_statements_are_printed(t *testing.T) {
+	output, errs := runPrompt(t, "var a = 1;\na + 1;\n\"s\";\nnil;\nprint a;\n")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	want := banner + "> > 2\n> \"s\"\n> <nil>\n> 1\n> \n"
+	if output != want {
+		t.Errorf("got output:\n%q\nwant:\n%q", output, want)
+	}
+}
+
+func Test_unclosed_brackets_at_end_of_input_are_errors(t *testing.T) {
+	_, errs := runPrompt(t, "{\nprint 1;\n")
+	if len(errs) != 1 {
+		t.Fatalf("got errors %v, want 1 error", errs)
+	}
+}
+
+func Test_closing_brackets_end_input(t *testing.T) {
+	// extra closing brackets are errors, instead of waiting for more lines
+	output, errs := runPrompt(t, "}\nprint 1;\n")
+	if len(errs) != 1 {
+		t.Fatalf("got errors %v, want 1 error", errs)
+	}
+	if !strings.HasSuffix(output, "> 1\n> \n") {
+		t.Errorf("got output:\n%q", output)
+	}
+}
+
+func Test_env_lists_globals(t *testing.T) {
+	output, errs := runPrompt(t, "var a = [1];\nfun f() {}\n:env\n")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	for _, want := range []string{"a = [1]\n", "f = <fn: f>\n", "clock = "} {
+		if !strings.Contains(output, want) {
+			t.Errorf("got output without %q:\n%s", want, output)
+		}
+	}
+}
+
+func Test_ast_prints_statements(t *testing.T) {
+	output, errs := runPrompt(t, ":ast var a = 1 + 2;\n")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if !strings.Contains(output, "var a = (+ (literal 1) (literal 2));\n") {
+		t.Errorf("got output:\n%s", output)
+	}
+}
+
+func Test_tokens_prints_tokens(t *testing.T) {
+	output, errs := runPrompt(t, ":tokens print x;\n")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	want := "1:1\tTokenTypePrint\tprint\n1:7\tTokenTypeIdentifier\tx\n1:8\tTokenTypeSemicolon\t;\n"
+	if !strings.Contains(output, want) {
+		t.Errorf("got output:\n%q\nwant:\n%q", output, want)
+	}
+}
+
+func Test_load_runs_a_file_in_the_session(t *testing.T) {
+	output, errs := runPrompt(t, ":load ../test_files/import/modules/math.lox\npi + offset();\n")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if !strings.Contains(output, "\"math loaded\"\n> 3\n") {
+		t.Errorf("got output:\n%s", output)
+	}
+}
+
+func Test_reset_drops_globals(t *testing.T) {
+	_, errs := runPrompt(t, "var a = 1;\n:reset\na;\n")
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "undefined variable 'a'") {
+		t.Errorf("got errors %v, want an undefined variable", errs)
+	}
+}
+
+func Test_time_measures_the_next_input(t *testing.T) {
+	output, errs := runPrompt(t, ":time\n1 + 1;\n2;\n")
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if n := strings.Count(output, "took "); n != 1 || !strings.Contains(output, "> 2\ntook ") {
+		t.Errorf("got output:\n%s", output)
+	}
+}
+
+func Test_debug_toggles_debug_logs(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	r := runner.NewRunner(golox.Config{
+		Stdin:  strings.NewReader(":debug on\nvar a = 1;\n:debug off\nvar b = 2;\n"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	r.RunPrompt(func(err error) { t.Error(err) })
+	if !strings.Contains(stderr.String(), "'a'") {
+		t.Errorf("got no debug logs of a:\n%s", stderr.String())
+	}
+	if strings.Contains(stderr.String(), "'b'") {
+		t.Errorf("got debug logs of b:\n%s", stderr.String())
+	}
+}
+
+func Test_invalid_commands_are_errors(t *testing.T) {
+	_, errs := runPrompt(t, ":unknown\n:debug maybe\n:load\n")
+	if len(errs) != 3 {
+		t.Errorf("got errors %v, want 3 errors", errs)
+	}
+}
+
+func Test_errors_render_the_input_defining_the_function(t *testing.T) {
+	var rendered bytes.Buffer
+	r := runner.NewRunner(golox.Config{
+		Stdin:  strings.NewReader("fun f() {\n  var b = 2;\n  return b + nil;\n}\nf();\n"),
+		Stdout: &bytes.Buffer{},
+	})
+	r.RunPrompt(func(err error) { r.RenderError(&rendered, err, false) })
+
+	want := strings.Join([]string{
+		` --> REPL#1:3:12`,
+		`  |`,
+		`3 |   return b + nil;`,
+		`  |            ^`,
+	}, "\n")
+	if got := rendered.String(); !strings.Contains(got, want) {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func Test_commands_do_not_use_up_input_numbers(t *testing.T) {
+	var rendered bytes.Buffer
+	r := runner.NewRunner(golox.Config{
+		Stdin:  strings.NewReader(":tokens \"a\n:ast 1 +;\nnil + 1;\n"),
+		Stdout: &bytes.Buffer{},
+	})
+	r.RunPrompt(func(err error) { r.RenderError(&rendered, err, false) })
+
+	// the errors of the commands are rendered with their code
+	for _, want := range []string{" --> REPL:1:3", "1 | \"a", " --> REPL:1:4", "1 | 1 +;", " --> REPL#1:1:5"} {
+		if got := rendered.String(); !strings.Contains(got, want) {
+			t.Errorf("got\n%s\nwant %q", got, want)
+		}
+	}
+	if got, want := r.Renderer().SrcPaths(), []string{"REPL#1"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("got sources %v, want %v", got, want)
+	}
+}
+
+func Test_many_inputs_do_not_grow_the_renderer(t *testing.T) {
+	r := runner.NewRunner(golox.Config{
+		Stdin:  strings.NewReader(strings.Repeat("1;\n", 1000)),
+		Stdout: &bytes.Buffer{},
+	})
+	r.RunPrompt(func(err error) { t.Error(err) })
+
+	if n := len(r.Renderer().SrcPaths()); n > 100 {
+		t.Errorf("got %d sources after 1000 inputs", n)
+	}
+}
@@ -0,0 +1,64 @@
+package benchmark_test
+
+import (
+	golox "golox/internal"
+	"golox/internal/runner"
+	"io"
+	"testing"
+)
+
+// run with: go test ./test/test_files/benchmark -bench . -benchtime 1x
+
+func benchmarkFile(b *testing.B, path string) {
+	r := runner.NewRunner(golox.Config{Stdout: io.Discard})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := r.RunFile(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_binary_trees(b *testing.B) {
+	benchmarkFile(b, "binary_trees.lox")
+}
+
+func Benchmark_equality(b *testing.B) {
+	benchmarkFile(b, "equality.lox")
+}
+
+func Benchmark_fib(b *testing.B) {
+	benchmarkFile(b, "fib.lox")
+}
+
+func Benchmark_instantiation(b *testing.B) {
+	benchmarkFile(b, "instantiation.lox")
+}
+
+func Benchmark_invocation(b *testing.B) {
+	benchmarkFile(b, "invocation.lox")
+}
+
+func Benchmark_method_call(b *testing.B) {
+	benchmarkFile(b, "method_call.lox")
+}
+
+func Benchmark_properties(b *testing.B) {
+	benchmarkFile(b, "properties.lox")
+}
+
+func Benchmark_string_equality(b *testing.B) {
+	benchmarkFile(b, "string_equality.lox")
+}
+
+func Benchmark_trees(b *testing.B) {
+	benchmarkFile(b, "trees.lox")
+}
+
+func Benchmark_zoo(b *testing.B) {
+	benchmarkFile(b, "zoo.lox")
+}
+
+func Benchmark_zoo_batch(b *testing.B) {
+	benchmarkFile(b, "zoo_batch.lox")
+}
@@ -2,6 +2,7 @@ package bool_test
 
 import (
 	"fmt"
+	golox "golox/internal"
 	"golox/internal/runner"
 	"os"
 	"testing"
@@ -22,7 +23,7 @@ var (
 )
 
 func TestMain(m *testing.M) {
-	r = runner.NewRunner(false)
+	r = runner.NewRunner(golox.Config{})
 
 	// run tests
 	os.Exit(m.Run())
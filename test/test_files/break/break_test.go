@@ -0,0 +1,103 @@
+package break_test
+
+import (
+	"fmt"
+	golox "golox/internal"
+	"golox/internal/runner"
+	"os"
+	"testing"
+)
+
+const (
+	ANSI_UNDERLINE = "\x1b[4m"
+	ANSI_FG_RED    = "\x1b[31m"
+	ANSI_FG_GREEN  = "\x1b[32m"
+	ANSI_RESET     = "\x1b[0m"
+
+	SUCCESS_TEXT = ANSI_UNDERLINE + "negative test " + ANSI_FG_GREEN + "SUCCESS" + ANSI_RESET
+	FAILED_TEXT  = ANSI_UNDERLINE + "negative test " + ANSI_FG_RED + "FAILED" + ANSI_RESET
+)
+
+var (
+	r *runner.Runner
+)
+
+func TestMain(m *testing.M) {
+	r = runner.NewRunner(golox.Config{})
+
+	// run tests
+	os.Exit(m.Run())
+}
+
+func Example_closure() {
+	if err := r.RunFile("closure.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "captured"
+	// "after"
+}
+
+func Example_for() {
+	if err := r.RunFile("for.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// 0
+	// 1
+}
+
+func Test_in_function_in_loop(t *testing.T) {
+	if err := r.RunFile("in_function_in_loop.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_missing_semicolon(t *testing.T) {
+	if err := r.RunFile("missing_semicolon.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Example_nested() {
+	if err := r.RunFile("nested.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// 0
+	// 1
+	// 2
+}
+
+func Test_outside_loop(t *testing.T) {
+	if err := r.RunFile("outside_loop.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Example_return_inside() {
+	if err := r.RunFile("return_inside.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "returned"
+}
+
+func Example_while() {
+	if err := r.RunFile("while.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// 2
+}
@@ -0,0 +1,175 @@
+package import_test
+
+import (
+	"fmt"
+	golox "golox/internal"
+	"golox/internal/runner"
+	"os"
+	"testing"
+)
+
+const (
+	ANSI_UNDERLINE = "\x1b[4m"
+	ANSI_FG_RED    = "\x1b[31m"
+	ANSI_FG_GREEN  = "\x1b[32m"
+	ANSI_RESET     = "\x1b[0m"
+
+	SUCCESS_TEXT = ANSI_UNDERLINE + "negative test " + ANSI_FG_GREEN + "SUCCESS" + ANSI_RESET
+	FAILED_TEXT  = ANSI_UNDERLINE + "negative test " + ANSI_FG_RED + "FAILED" + ANSI_RESET
+)
+
+var (
+	r *runner.Runner
+)
+
+func TestMain(m *testing.M) {
+	r = runner.NewRunner(golox.Config{})
+
+	// run tests
+	os.Exit(m.Run())
+}
+
+func Example_cached() {
+	if err := r.RunFile("cached.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "math loaded"
+	// true
+	// 3
+}
+
+func Example_cycle() {
+	if err := r.RunFile("cycle.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "import cycle: cycle_a.lox -> cycle_b.lox -> cycle_a.lox"
+}
+
+func Test_export_in_block(t *testing.T) {
+	if err := r.RunFile("export_in_block.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_export_statement(t *testing.T) {
+	if err := r.RunFile("export_statement.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Example_from_import() {
+	if err := r.RunFile("from_import.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "math loaded"
+	// 9
+	// 2
+}
+
+func Example_import_as() {
+	if err := r.RunFile("import_as.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "math loaded"
+	// <module: math.lox>
+	// 3
+	// 16
+	// 3
+}
+
+func Example_local() {
+	if err := r.RunFile("local.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "math loaded"
+	// 9
+	// "local"
+	// 3
+}
+
+func Test_missing_as(t *testing.T) {
+	if err := r.RunFile("missing_as.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_missing_names(t *testing.T) {
+	if err := r.RunFile("missing_names.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Example_module_error() {
+	if err := r.RunFile("module_error.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "operands must be both numbers or both strings"
+	// 1
+}
+
+func Example_nested() {
+	if err := r.RunFile("nested.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "math loaded"
+	// 12
+}
+
+func Test_not_exported(t *testing.T) {
+	if err := r.RunFile("not_exported.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Example_not_exported_property() {
+	if err := r.RunFile("not_exported_property.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "math loaded"
+	// "undefined export 'offset' of module math.lox"
+}
+
+func Example_not_found() {
+	if err := r.RunFile("not_found.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "E0020"
+}
+
+func Example_module_path() {
+	r := runner.NewRunner(golox.Config{ModulePaths: []string{"modules/path"}})
+	if err := r.RunFile("modules/search_path.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "hello lox"
+}
@@ -0,0 +1,139 @@
+package lambda_test
+
+import (
+	"fmt"
+	golox "golox/internal"
+	"golox/internal/runner"
+	"os"
+	"testing"
+)
+
+const (
+	ANSI_UNDERLINE = "\x1b[4m"
+	ANSI_FG_RED    = "\x1b[31m"
+	ANSI_FG_GREEN  = "\x1b[32m"
+	ANSI_RESET     = "\x1b[0m"
+
+	SUCCESS_TEXT = ANSI_UNDERLINE + "negative test " + ANSI_FG_GREEN + "SUCCESS" + ANSI_RESET
+	FAILED_TEXT  = ANSI_UNDERLINE + "negative test " + ANSI_FG_RED + "FAILED" + ANSI_RESET
+)
+
+var (
+	r *runner.Runner
+)
+
+func TestMain(m *testing.M) {
+	r = runner.NewRunner(golox.Config{})
+
+	// run tests
+	os.Exit(m.Run())
+}
+
+func Example_anonymous() {
+	if err := r.RunFile("anonymous.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// 3
+	// <nil>
+	// 16
+}
+
+func Example_arrow() {
+	if err := r.RunFile("arrow.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// 42
+	// "ab"
+	// 42
+	// true
+	// {"a": 1}
+	// 2
+	// 1
+}
+
+func Example_callback() {
+	if err := r.RunFile("callback.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// [10, 20, 30]
+	// [1, "two", 3]
+}
+
+func Example_closure() {
+	if err := r.RunFile("closure.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// 1
+	// 2
+	// 15
+	// 6
+}
+
+func Test_missing_arrow_body(t *testing.T) {
+	if err := r.RunFile("missing_arrow_body.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_missing_body(t *testing.T) {
+	if err := r.RunFile("missing_body.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Example_name() {
+	if err := r.RunFile("name.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// <fn: anonymous@name.lox:1>
+	// <fn: anonymous@name.lox:3>
+}
+
+func Test_return_in_arrow(t *testing.T) {
+	if err := r.RunFile("return_in_arrow.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_runtime_error(t *testing.T) {
+	if err := r.RunFile("runtime_error.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Example_statement() {
+	if err := r.RunFile("statement.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "called"
+	// "done"
+}
+
+func Example_this() {
+	if err := r.RunFile("this.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// 2
+}
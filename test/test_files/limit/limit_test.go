@@ -0,0 +1,67 @@
+package limit_test
+
+import (
+	"errors"
+	golox "golox/internal"
+	"golox/internal/runner"
+	"testing"
+)
+
+// The other limits in this directory are the limits of clox, which do not
+// apply to golox.
+
+func Test_stack_overflow(t *testing.T) {
+	r := runner.NewRunner(golox.Config{})
+
+	err := r.RunFile("stack_overflow.lox")
+
+	var diag *golox.Diagnostic
+	if !errors.As(err, &diag) {
+		t.Fatalf("got %v, want a diagnostic", err)
+	}
+	if diag.Code != golox.ErrorCodeStackOverflow {
+		t.Errorf("got %s, want %s", diag.Code, golox.ErrorCodeStackOverflow)
+	}
+	if want := "stack overflow at depth 1024"; diag.Message != want {
+		t.Errorf("got %q, want %q", diag.Message, want)
+	}
+	if len(diag.StackTrace) != golox.DefaultMaxCallDepth {
+		t.Errorf("got %d frames, want %d", len(diag.StackTrace), golox.DefaultMaxCallDepth)
+	}
+
+	// the runner is still usable after the overflow
+	if val, err := r.RunSource([]rune("fun f(n) { return n; } f(1);"), "<test>"); err != nil {
+		t.Fatal(err)
+	} else if val != 1.0 {
+		t.Errorf("got %v, want 1", val)
+	}
+}
+
+func Test_stack_overflow_max_call_depth(t *testing.T) {
+	r := runner.NewRunner(golox.Config{MaxCallDepth: 10})
+
+	source := "fun f(n) { return f(n + 1); } f(0);"
+	_, err := r.RunSource([]rune(source), "<test>")
+
+	var diag *golox.Diagnostic
+	if !errors.As(err, &diag) {
+		t.Fatalf("got %v, want a diagnostic", err)
+	}
+	if want := "stack overflow at depth 10"; diag.Message != want {
+		t.Errorf("got %q, want %q", diag.Message, want)
+	}
+	if len(diag.StackTrace) != 10 {
+		t.Errorf("got %d frames, want 10", len(diag.StackTrace))
+	}
+}
+
+func Test_recursion_within_max_call_depth(t *testing.T) {
+	r := runner.NewRunner(golox.Config{MaxCallDepth: 100})
+
+	source := "fun f(n) { if (n == 0) return 0; return 1 + f(n - 1); } f(99);"
+	if val, err := r.RunSource([]rune(source), "<test>"); err != nil {
+		t.Fatal(err)
+	} else if val != 99.0 {
+		t.Errorf("got %v, want 99", val)
+	}
+}
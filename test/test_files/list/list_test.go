@@ -0,0 +1,177 @@
+package list_test
+
+import (
+	"fmt"
+	golox "golox/internal"
+	"golox/internal/runner"
+	"os"
+	"testing"
+)
+
+const (
+	ANSI_UNDERLINE = "\x1b[4m"
+	ANSI_FG_RED    = "\x1b[31m"
+	ANSI_FG_GREEN  = "\x1b[32m"
+	ANSI_RESET     = "\x1b[0m"
+
+	SUCCESS_TEXT = ANSI_UNDERLINE + "negative test " + ANSI_FG_GREEN + "SUCCESS" + ANSI_RESET
+	FAILED_TEXT  = ANSI_UNDERLINE + "negative test " + ANSI_FG_RED + "FAILED" + ANSI_RESET
+)
+
+var (
+	r *runner.Runner
+)
+
+func TestMain(m *testing.M) {
+	r = runner.NewRunner(golox.Config{})
+
+	// run tests
+	os.Exit(m.Run())
+}
+
+func Example_identity() {
+	if err := r.RunFile("identity.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// [1, 2]
+	// true
+	// false
+	// false
+	// true
+	// [1, 2, [...]]
+}
+
+func Example_index() {
+	if err := r.RunFile("index.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "a"
+	// "c"
+	// "c"
+	// "a"
+	// ["a", "B", "C"]
+	// "A"
+	// [30, 4]
+}
+
+func Test_index_not_integer(t *testing.T) {
+	if err := r.RunFile("index_not_integer.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_index_not_list(t *testing.T) {
+	if err := r.RunFile("index_not_list.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_index_not_number(t *testing.T) {
+	if err := r.RunFile("index_not_number.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_index_out_of_range(t *testing.T) {
+	if err := r.RunFile("index_out_of_range.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Example_literal() {
+	if err := r.RunFile("literal.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// []
+	// [1, "two", true, <nil>]
+	// [[1, 2], [3]]
+	// [3, "ab"]
+}
+
+func Example_methods() {
+	if err := r.RunFile("methods.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// [1, 2, 3]
+	// 3
+	// 3
+	// 2
+	// ["first", 1, "before last", 2, "last"]
+	// 1
+	// "last"
+	// ["first", "before last", 2]
+	// true
+	// false
+	// "pushed"
+}
+
+func Test_negative_index_out_of_range(t *testing.T) {
+	if err := r.RunFile("negative_index_out_of_range.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_pop_empty(t *testing.T) {
+	if err := r.RunFile("pop_empty.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_remove_out_of_range(t *testing.T) {
+	if err := r.RunFile("remove_out_of_range.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Example_slice() {
+	if err := r.RunFile("slice.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// [1, 2]
+	// [2, 3, 4]
+	// [3, 4]
+	// [1, 2, 3]
+	// []
+	// [0, 1, 2, 3, 4]
+	// 0
+}
+
+func Test_unclosed(t *testing.T) {
+	if err := r.RunFile("unclosed.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_undefined_method(t *testing.T) {
+	if err := r.RunFile("undefined_method.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
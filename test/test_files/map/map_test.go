@@ -0,0 +1,181 @@
+package map_test
+
+import (
+	"fmt"
+	golox "golox/internal"
+	"golox/internal/runner"
+	"os"
+	"testing"
+)
+
+const (
+	ANSI_UNDERLINE = "\x1b[4m"
+	ANSI_FG_RED    = "\x1b[31m"
+	ANSI_FG_GREEN  = "\x1b[32m"
+	ANSI_RESET     = "\x1b[0m"
+
+	SUCCESS_TEXT = ANSI_UNDERLINE + "negative test " + ANSI_FG_GREEN + "SUCCESS" + ANSI_RESET
+	FAILED_TEXT  = ANSI_UNDERLINE + "negative test " + ANSI_FG_RED + "FAILED" + ANSI_RESET
+)
+
+var (
+	r *runner.Runner
+)
+
+func TestMain(m *testing.M) {
+	r = runner.NewRunner(golox.Config{})
+
+	// run tests
+	os.Exit(m.Run())
+}
+
+func Example_identity() {
+	if err := r.RunFile("identity.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// {"a": 1, "b": 2}
+	// true
+	// false
+	// {"a": 1, "b": 2, "self": {...}}
+}
+
+func Example_index() {
+	if err := r.RunFile("index.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// 1
+	// {"a": 10, "b": 20}
+	// 30
+	// {"inner": {"x": 2}}
+	// "v"
+}
+
+func Test_instance_key(t *testing.T) {
+	if err := r.RunFile("instance_key.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Example_keys() {
+	if err := r.RunFile("keys.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "string"
+	// "number"
+	// "boolean"
+	// "nil"
+	// "string"
+	// "number"
+	// "boolean"
+	// "still zero"
+	// 5
+}
+
+func Test_list_key(t *testing.T) {
+	if err := r.RunFile("list_key.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Example_literal() {
+	if err := r.RunFile("literal.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// {}
+	// {"a": 1, "b": "two"}
+	// {1: "one", true: false, <nil>: <nil>}
+	// {"list": [1, 2], "map": {"x": 0}}
+	// {"ab": 3}
+	// {"a": 3, "b": 2}
+}
+
+func Example_methods() {
+	if err := r.RunFile("methods.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// 3
+	// ["a", "b", "c"]
+	// [1, 2, 3]
+	// true
+	// false
+	// false
+	// true
+	// false
+	// {"a": 1, "c": 3}
+	// true
+	// ["a", "c", "n", "d"]
+}
+
+func Test_missing_colon(t *testing.T) {
+	if err := r.RunFile("missing_colon.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_nan_key(t *testing.T) {
+	if err := r.RunFile("nan_key.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Example_order() {
+	if err := r.RunFile("order.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// {"z": 1, "y": 2, "x": 3}
+	// ["z", "y", "x"]
+	// ["y", "x", "z"]
+	// "yxz"
+}
+
+func Test_statement_start(t *testing.T) {
+	if err := r.RunFile("statement_start.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_unclosed(t *testing.T) {
+	if err := r.RunFile("unclosed.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_undefined_key(t *testing.T) {
+	if err := r.RunFile("undefined_key.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_undefined_method(t *testing.T) {
+	if err := r.RunFile("undefined_method.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
@@ -1,6 +1,7 @@
 package print_test
 
 import (
+	golox "golox/internal"
 	"golox/internal/runner"
 	"os"
 	"testing"
@@ -21,7 +22,7 @@ var (
 )
 
 func TestMain(m *testing.M) {
-	r = runner.NewRunner(false)
+	r = runner.NewRunner(golox.Config{})
 
 	// run tests
 	os.Exit(m.Run())
@@ -0,0 +1,192 @@
+package try_test
+
+import (
+	"fmt"
+	golox "golox/internal"
+	"golox/internal/runner"
+	"os"
+	"testing"
+)
+
+const (
+	ANSI_UNDERLINE = "\x1b[4m"
+	ANSI_FG_RED    = "\x1b[31m"
+	ANSI_FG_GREEN  = "\x1b[32m"
+	ANSI_RESET     = "\x1b[0m"
+
+	SUCCESS_TEXT = ANSI_UNDERLINE + "negative test " + ANSI_FG_GREEN + "SUCCESS" + ANSI_RESET
+	FAILED_TEXT  = ANSI_UNDERLINE + "negative test " + ANSI_FG_RED + "FAILED" + ANSI_RESET
+)
+
+var (
+	r *runner.Runner
+)
+
+func TestMain(m *testing.M) {
+	r = runner.NewRunner(golox.Config{})
+
+	// run tests
+	os.Exit(m.Run())
+}
+
+func Example_catch_runtime_error() {
+	if err := r.RunFile("catch_runtime_error.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "operands must be both numbers or both strings"
+	// 2
+	// "E0002"
+	// "undefined variable 'undefined'"
+	// "function call (call (getVar f) [(literal 1)]) expected 2 arguments, got 1"
+}
+
+func Example_catch_scope() {
+	if err := r.RunFile("catch_scope.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "caught"
+	// "caught local"
+	// "global"
+}
+
+func Example_catch_thrown() {
+	if err := r.RunFile("catch_thrown.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "boom"
+	// "after"
+}
+
+func Test_error_value(t *testing.T) {
+	if err := r.RunFile("error_value.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Example_finally() {
+	if err := r.RunFile("finally.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "body"
+	// "finally"
+	// "error"
+	// "finally"
+	// "inner finally"
+	// "inner"
+	// "finally"
+	// "second"
+}
+
+func Example_finally_loop() {
+	if err := r.RunFile("finally_loop.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "finally"
+	// 1
+	// "finally"
+	// "finally"
+	// "after"
+}
+
+func Example_finally_return() {
+	if err := r.RunFile("finally_return.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "finally"
+	// "local"
+	// "finally"
+	// "finally"
+}
+
+func Test_missing_catch_or_finally(t *testing.T) {
+	if err := r.RunFile("missing_catch_or_finally.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_missing_catch_variable(t *testing.T) {
+	if err := r.RunFile("missing_catch_variable.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_missing_semicolon(t *testing.T) {
+	if err := r.RunFile("missing_semicolon.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Example_nested() {
+	if err := r.RunFile("nested.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "deep"
+	// 2
+}
+
+func Example_rethrow() {
+	if err := r.RunFile("rethrow.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// true
+	// 5
+}
+
+func Example_stack_overflow() {
+	if err := r.RunFile("stack_overflow.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// "stack overflow at depth 1024"
+	// "recovered"
+}
+
+func Example_stack_trace() {
+	if err := r.RunFile("stack_trace.lox"); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// 2
+}
+
+func Test_uncaught(t *testing.T) {
+	if err := r.RunFile("uncaught.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
+
+func Test_uncaught_runtime_error(t *testing.T) {
+	if err := r.RunFile("uncaught_runtime_error.lox"); err != nil {
+		t.Log(SUCCESS_TEXT+":", err)
+	} else {
+		t.Error(FAILED_TEXT)
+	}
+}
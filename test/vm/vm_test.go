@@ -0,0 +1,95 @@
+package vm_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	golox "golox/internal"
+	"golox/internal/runner"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testFilesDir = "../test_files"
+)
+
+// runFile runs the script at path on backend, and returns its outputs and the
+// message of its error.
+func runFile(t *testing.T, backend golox.Backend, path string) (string, string) {
+	t.Helper()
+
+	var stdout bytes.Buffer
+	r := runner.NewRunner(golox.Config{Backend: backend, Stdout: &stdout})
+	if err := r.RunFile(path); err != nil {
+		return stdout.String(), err.Error()
+	}
+	return stdout.String(), ""
+}
+
+func Test_vm_matches_tree_walk_interpreter(t *testing.T) {
+	err := filepath.WalkDir(testFilesDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if d.IsDir() && (d.Name() == "benchmark" || d.Name() == "limit") {
+			// benchmarks are slow, and the compiler has its own limits
+			return filepath.SkipDir
+		} else if d.IsDir() || !strings.HasSuffix(path, ".lox") {
+			return nil
+		}
+
+		t.Run(strings.TrimPrefix(path, testFilesDir+"/"), func(t *testing.T) {
+			wantOutput, wantErr := runFile(t, golox.BackendTreeWalk, path)
+			gotOutput, gotErr := runFile(t, golox.BackendVM, path)
+			if gotOutput != wantOutput {
+				t.Errorf("got output:\n%s\nwant:\n%s", gotOutput, wantOutput)
+			}
+			if gotErr != wantErr {
+				t.Errorf("got error:\n%s\nwant:\n%s", gotErr, wantErr)
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_compiler_limits(t *testing.T) {
+	for path, code := range map[string]golox.ErrorCode{
+		"too_many_locals.lox":   golox.ErrorCodeTooManyLocals,
+		"too_many_upvalues.lox": golox.ErrorCodeTooManyUpvalues,
+		"loop_too_large.lox":    golox.ErrorCodeJumpTooLarge,
+	} {
+		r := runner.NewRunner(golox.Config{Backend: golox.BackendVM})
+		err := r.RunFile(filepath.Join(testFilesDir, "limit", path))
+
+		var diag *golox.Diagnostic
+		if !errors.As(err, &diag) {
+			t.Errorf("%s: got %v, want a diagnostic", path, err)
+		} else if diag.Phase != golox.PhaseCompiler || diag.Code != code {
+			t.Errorf("%s: got %s error %s, want compiler error %s", path, diag.Phase, diag.Code, code)
+		}
+	}
+}
+
+func Test_stack_overflow(t *testing.T) {
+	r := runner.NewRunner(golox.Config{Backend: golox.BackendVM, MaxCallDepth: 100})
+	_, err := r.RunSource([]rune("fun f(n) { return f(n + 1); } f(0);"), "test")
+
+	var diag *golox.Diagnostic
+	if !errors.As(err, &diag) || diag.Code != golox.ErrorCodeStackOverflow {
+		t.Fatalf("got %v, want a stack overflow", err)
+	} else if len(diag.StackTrace) != 100 {
+		t.Errorf("got %d stack frames, want 100", len(diag.StackTrace))
+	}
+
+	// the VM is usable after the error
+	if val, err := r.RunSource([]rune("f;"), "test"); err != nil {
+		t.Fatal(err)
+	} else if fmt.Sprint(val) != "<fn: f>" {
+		t.Errorf("got %v, want <fn: f>", val)
+	}
+}
@@ -0,0 +1,155 @@
+package golox
+
+import (
+	"fmt"
+	"golox/internal/interpreter"
+	"reflect"
+)
+
+type Kind int
+
+const (
+	KindNil Kind = iota
+	KindBool
+	KindNumber
+	KindString
+	KindFunction
+	KindClass
+	KindInstance
+	KindList
+	KindMap
+	KindError
+	KindModule
+)
+
+// KindUnknown is the kind of the values of other Go types, which Lox programs
+// do not create.
+const KindUnknown Kind = -1
+
+func (k Kind) String() string {
+	switch k {
+	case KindNil:
+		return "nil"
+	case KindBool:
+		return "bool"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindFunction:
+		return "function"
+	case KindClass:
+		return "class"
+	case KindInstance:
+		return "instance"
+	case KindList:
+		return "list"
+	case KindMap:
+		return "map"
+	case KindError:
+		return "error"
+	case KindModule:
+		return "module"
+	case KindUnknown:
+		return "unknown"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Value is a Lox value. The zero Value is nil.
+type Value struct {
+	raw any
+}
+
+// Kind returns the kind of v, KindUnknown if v holds a Go value that is not a
+// Lox value.
+func (v Value) Kind() Kind {
+	switch v.raw.(type) {
+	case nil:
+		return KindNil
+	case bool:
+		return KindBool
+	case float64:
+		return KindNumber
+	case string:
+		return KindString
+	case *interpreter.LoxClass:
+		// check before LoxCallable, as classes are also callable
+		return KindClass
+	case interpreter.LoxCallable:
+		return KindFunction
+	case *interpreter.LoxInstance:
+		return KindInstance
+	case *interpreter.LoxList:
+		return KindList
+	case *interpreter.LoxMap:
+		return KindMap
+	case *interpreter.LoxError:
+		return KindError
+	case *interpreter.LoxModule:
+		return KindModule
+	default:
+		return KindUnknown
+	}
+}
+
+func (v Value) IsNil() bool {
+	return v.raw == nil
+}
+
+func (v Value) AsBool() (bool, bool) {
+	val, ok := v.raw.(bool)
+	return val, ok
+}
+
+func (v Value) AsNumber() (float64, bool) {
+	val, ok := v.raw.(float64)
+	return val, ok
+}
+
+func (v Value) AsString() (string, bool) {
+	val, ok := v.raw.(string)
+	return val, ok
+}
+
+// Interface returns the underlying Go value, i.e. one of nil, bool, float64,
+// string, or a pointer to an interpreter object, e.g. *interpreter.LoxList.
+func (v Value) Interface() any {
+	return v.raw
+}
+
+// String formats v the same way as a Lox print statement.
+func (v Value) String() string {
+	return interpreter.Stringify(v.raw)
+}
+
+// ValueOf converts a Go value to a Lox value. Go numbers are converted to
+// float64. A Value is returned as is.
+func ValueOf(val any) (Value, error) {
+	switch val := val.(type) {
+	case nil, bool, float64, string:
+		return Value{raw: val}, nil
+	case Value:
+		return val, nil
+	case interpreter.LoxCallable, *interpreter.LoxInstance, *interpreter.LoxList,
+		*interpreter.LoxMap, *interpreter.LoxError, *interpreter.LoxModule:
+		return Value{raw: val}, nil
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return Value{raw: rv.Bool()}, nil
+	case reflect.String:
+		return Value{raw: rv.String()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Value{raw: float64(rv.Int())}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Value{raw: float64(rv.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return Value{raw: rv.Float()}, nil
+	}
+
+	return Value{}, fmt.Errorf("cannot convert Go type %T to a Lox value", val)
+}